@@ -1,6 +1,8 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
 
@@ -8,12 +10,51 @@ import (
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatalf("Usage: %s <static-dir>", os.Args[0])
+	out := flag.String("out", "", "Write minified files to this directory")
+	check := flag.Bool("check", false, "Exit with status 1 if any file would change")
+	compress := flag.Bool("compress", false, "With --out, also write .br, .zst and .gz siblings of text assets")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s (--out <dir> [--compress] | --check) <static-dir>\n", os.Args[0])
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	staticDir := os.Args[1]
-	if err := minify.MinifyStaticFiles(staticDir); err != nil {
+	if flag.NArg() != 1 || (*out == "") == !*check {
+		flag.Usage()
+		os.Exit(2)
+	}
+	staticDir := flag.Arg(0)
+
+	if *check {
+		results, err := minify.CheckDir(staticDir)
+		if err != nil {
+			log.Fatalf("Error checking files: %v", err)
+		}
+
+		changed := 0
+		for _, r := range results {
+			if r.Changed() {
+				changed++
+				fmt.Printf("%s: %d -> %d bytes\n", r.Path, r.OriginalSize, r.MinifiedSize)
+			}
+		}
+		if changed > 0 {
+			fmt.Printf("%d file(s) would change\n", changed)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var opts []minify.WriteOption
+	if *compress {
+		opts = append(opts, minify.WithPrecompression())
+	}
+
+	results, err := minify.WriteDir(staticDir, *out, opts...)
+	if err != nil {
 		log.Fatalf("Error minifying files: %v", err)
 	}
+	for _, r := range results {
+		fmt.Printf("%s: %d -> %d bytes\n", r.Path, r.OriginalSize, r.MinifiedSize)
+	}
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/assets"
+	"github.com/spf13/cobra"
+)
+
+// newAssetsCommand returns the "assets" command for managing front-end assets
+func newAssetsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "assets",
+		Short: "Manage front-end assets",
+	}
+
+	var dir string
+	vendorCmd := &cobra.Command{
+		Use:   "vendor",
+		Short: "Download pinned third-party scripts into the static directory",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client := &http.Client{Timeout: 30 * time.Second}
+			if err := assets.Fetch(cmd.Context(), client, dir); err != nil {
+				return err
+			}
+
+			for _, v := range assets.Vendored {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s <- %s\n", v.Name, v.URL)
+			}
+			return nil
+		},
+	}
+	vendorCmd.Flags().StringVar(&dir, "dir", "web/static", "Static files directory")
+
+	cmd.AddCommand(vendorCmd)
+	return cmd
+}
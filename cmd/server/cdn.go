@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/assets"
+	"github.com/lexfrei/ne-stat-toboy/internal/cdn"
+	"github.com/lexfrei/ne-stat-toboy/internal/config"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+	"github.com/lexfrei/ne-stat-toboy/internal/tracing"
+)
+
+// newPurgeBatcher creates the CDN purger from configuration, or returns nil
+// if purging is not configured
+func newPurgeBatcher() *cdn.Batcher {
+	cfg := config.AppConfig.CDN
+	if cfg.ZoneID == "" || cfg.APIToken == "" {
+		slog.Warn("NESTAT_CDN_ZONEID or NESTAT_CDN_APITOKEN environment variable not set - CDN cache will not be purged")
+		return nil
+	}
+
+	purger := cdn.NewCloudflare(cfg.ZoneID, cfg.APIToken,
+		cdn.WithBaseURL(cfg.APIURL),
+		cdn.WithHTTPClient(&http.Client{
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport(nil),
+		}),
+	)
+	return cdn.NewBatcher(purger, cfg.BatchWindow)
+}
+
+// purgeChangedAssets queues the assets changed since the last start for
+// purging, along with the cached pages that reference them by fingerprinted
+// URL. The new state is saved once the purge succeeded, so that changes are
+// purged again after a restart until they are.
+func purgeChangedAssets(manifest *assets.Manifest, purges *cdn.Batcher) {
+	statePath := filepath.Join(config.AppConfig.Storage.Dir, "assets-state.json")
+
+	changed, err := manifest.ChangedSince(statePath)
+	if err != nil {
+		slog.Error("Failed to compare assets with the last deploy", "error", err)
+		return
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	pages := cachedPages()
+	paths := make([]string, 0, len(changed)+len(pages))
+	for _, name := range changed {
+		paths = append(paths, "/static/"+name)
+	}
+	paths = append(paths, pages...)
+
+	slog.Info("Static assets changed since the last deploy", "assets", len(changed))
+	purges.AddThen(func() {
+		if err := manifest.SaveState(statePath); err != nil {
+			slog.Error("Failed to save asset state", "error", err)
+		}
+	}, siteURLs(paths...)...)
+}
+
+// cachedPages returns the public pages that the edge cache keeps: none if
+// consent personalizes them, and never the contact form
+func cachedPages() []string {
+	if consent.Personalized() {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(handler.PublicPages), func(page string) bool {
+		return page == "/contact"
+	})
+}
+
+// siteURLs turns paths into absolute URLs of the public site
+func siteURLs(paths ...string) []string {
+	base := strings.TrimRight(config.AppConfig.Site.BaseURL, "/")
+
+	urls := make([]string, 0, len(paths))
+	for _, p := range paths {
+		urls = append(urls, base+p)
+	}
+	return urls
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/config"
+	"github.com/lexfrei/ne-stat-toboy/internal/export"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+	"github.com/lexfrei/ne-stat-toboy/internal/maintenance"
+	"github.com/lexfrei/ne-stat-toboy/internal/pagecache"
+	"github.com/spf13/cobra"
+)
+
+// newExportCommand returns the "export" command that renders the public site
+// into static files, as a fallback for when the server is down
+func newExportCommand() *cobra.Command {
+	var out, apiURL string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Render the public site into a directory for a static host",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if apiURL == "" {
+				apiURL = config.AppConfig.Site.BaseURL
+			}
+			if !strings.HasPrefix(apiURL, "http://") && !strings.HasPrefix(apiURL, "https://") {
+				return fmt.Errorf("API URL must be absolute, got %q", apiURL)
+			}
+
+			manifest, err := buildManifest()
+			if err != nil {
+				return fmt.Errorf("failed to build asset manifest: %w", err)
+			}
+
+			signer, err := newSigner()
+			if err != nil {
+				return err
+			}
+
+			h := handler.New(
+				handler.WithConfirmationLinks(signer, config.AppConfig.Site.BaseURL, config.AppConfig.Contact.ConfirmationTTL),
+				handler.WithPrivacyPolicy(config.AppConfig.Privacy.PolicyVersion, config.AppConfig.Privacy.Retention),
+				handler.WithAPIURL(apiURL),
+			)
+			// Pages are rendered in-process, so rate limiting is left off
+			e := newRouter(site{
+				handler:     h,
+				manifest:    manifest,
+				maintenance: &maintenance.Mode{},
+				pageCache:   pagecache.New(),
+			})
+
+			urls := append([]string{}, handler.PublicPages...)
+			urls = append(urls, "/sitemap.xml")
+			for _, a := range manifest.Assets() {
+				urls = append(urls, a.URL, "/static/"+a.Name)
+			}
+
+			results, err := export.Write(cmd.Context(), e, out, urls)
+			if err != nil {
+				return err
+			}
+
+			broken, err := export.Verify(out)
+			if err != nil {
+				return err
+			}
+			for _, b := range broken {
+				fmt.Fprintf(cmd.ErrOrStderr(), "broken link %s\n", b)
+			}
+			if len(broken) > 0 {
+				return errors.New("export has broken internal links")
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "exported %d files to %s, forms post to %s\n", len(results), out, apiURL)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&out, "out", config.AppConfig.Export.Dir, "Output directory")
+	cmd.Flags().StringVar(&apiURL, "api-url", config.AppConfig.Export.APIURL, "Absolute URL of the server the contact form posts to (default: site base URL)")
+
+	return cmd
+}
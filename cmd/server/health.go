@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/assets"
+	"github.com/lexfrei/ne-stat-toboy/internal/config"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+	"github.com/lexfrei/ne-stat-toboy/internal/health"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+)
+
+// requiredAssets are the static files every page references
+var requiredAssets = []string{"css/style.css", "js/analytics.js"}
+
+// addHealthChecks registers the readiness checks of the site's dependencies
+func addHealthChecks(checker *health.Checker, h *handler.Handler, manifest *assets.Manifest, telegramClient *telegram.Client) {
+	checker.Add("content", func(context.Context) error {
+		if h.FilmInfo.Title == "" {
+			return errors.New("film information is not loaded")
+		}
+		return nil
+	})
+
+	checker.Add("storage", health.Writable(config.AppConfig.Storage.Dir))
+
+	checker.Add("assets", func(context.Context) error {
+		for _, name := range requiredAssets {
+			if _, ok := manifest.Lookup(name); !ok {
+				return fmt.Errorf("asset %s is missing", name)
+			}
+		}
+		return nil
+	})
+
+	// Telegram limits API calls, so the notifier is asked at most once a minute
+	if telegramClient != nil {
+		checker.Add("telegram", health.Cached(func(ctx context.Context) error {
+			_, err := telegramClient.GetMe(ctx)
+			return err
+		}, time.Minute))
+	}
+}
@@ -4,156 +4,298 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/a-h/templ"
 	"github.com/cockroachdb/errors"
-	"github.com/labstack/echo-contrib/prometheus"
-	"github.com/labstack/echo/v4"
-	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/lexfrei/ne-stat-toboy/internal/analytics"
+	"github.com/lexfrei/ne-stat-toboy/internal/assets"
+	"github.com/lexfrei/ne-stat-toboy/internal/bot"
 	"github.com/lexfrei/ne-stat-toboy/internal/config"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+	"github.com/lexfrei/ne-stat-toboy/internal/csp"
+	"github.com/lexfrei/ne-stat-toboy/internal/geoip"
 	"github.com/lexfrei/ne-stat-toboy/internal/handler"
-	"github.com/lexfrei/ne-stat-toboy/internal/middleware"
-	"github.com/lexfrei/ne-stat-toboy/internal/minify"
-	prom "github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/collectors"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/lexfrei/ne-stat-toboy/internal/health"
+	"github.com/lexfrei/ne-stat-toboy/internal/logging"
+	"github.com/lexfrei/ne-stat-toboy/internal/mailer"
+	"github.com/lexfrei/ne-stat-toboy/internal/maintenance"
+	"github.com/lexfrei/ne-stat-toboy/internal/pagecache"
+	"github.com/lexfrei/ne-stat-toboy/internal/privacy"
+	"github.com/lexfrei/ne-stat-toboy/internal/scanner"
+	"github.com/lexfrei/ne-stat-toboy/internal/signing"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+	"github.com/lexfrei/ne-stat-toboy/internal/tracing"
+	"github.com/lexfrei/ne-stat-toboy/internal/upload"
+	"github.com/lexfrei/ne-stat-toboy/internal/version"
+	"github.com/lexfrei/ne-stat-toboy/web"
+	"github.com/spf13/cobra"
 )
 
 func main() {
 	// Setup logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(logging.NewContextHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})))
 	slog.SetDefault(logger)
 
 	// Initialize configuration
 	config.Initialize()
+
+	// Switch to the configured log level and format
+	logger, err := logging.New(os.Stdout, logging.Options{
+		Format:     config.AppConfig.Log.Format,
+		Level:      config.AppConfig.Log.Level,
+		Secrets:    config.Secrets(),
+		RedactKeys: config.AppConfig.Log.RedactKeys,
+	})
+	if err != nil {
+		slog.Error("Invalid logging configuration", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	config.WarnMissing()
+
 	rootCmd := config.InitCommands()
+	rootCmd.Run = func(*cobra.Command, []string) { serve() }
+	rootCmd.AddCommand(newPrivacyCommand(), newAssetsCommand(), newExportCommand())
 
-	// If called with arguments, let cobra handle it
-	if len(os.Args) > 1 {
-		if err := rootCmd.Execute(); err != nil {
-			slog.Error("Command execution failed", "error", err)
-			os.Exit(1)
-		}
-		return
+	if err := rootCmd.Execute(); err != nil {
+		slog.Error("Command execution failed", "error", err)
+		os.Exit(1)
 	}
+}
 
+// serve runs the website until it receives SIGINT or SIGTERM
+func serve() {
 	// Create main context
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Minify static files
-	staticDir := "web/static"
-	slog.Info("Minifying static files", "directory", staticDir)
-	if err := minify.MinifyStaticFiles(staticDir); err != nil {
-	 slog.Error("Failed to minify static files", "error", err)
-	 // Continue execution even if minification fails
+	// Export traces of requests, rendering and outbound calls
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    config.AppConfig.Tracing.Exporter,
+		Endpoint:    config.AppConfig.Tracing.Endpoint,
+		Insecure:    config.AppConfig.Tracing.Insecure,
+		SampleRatio: config.AppConfig.Tracing.SampleRatio,
+	})
+	if err != nil {
+		slog.Error("Failed to setup tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Fingerprint, minify and precompress static files in memory
+	manifest, err := buildManifest()
+	if err != nil {
+		slog.Error("Failed to build asset manifest", "error", err)
+		os.Exit(1)
+	}
+
+	// Setup malware scanning for uploads
+	guard, err := newScannerGuard()
+	if err != nil {
+		slog.Error("Invalid scanner configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Open submission storage
+	submissions, err := openSubmissions()
+	if err != nil {
+		slog.Error("Failed to open submission storage", "error", err)
+		os.Exit(1)
+	}
+
+	// Purge submissions past the retention period
+	go privacy.RunRetention(ctx, submissions, config.AppConfig.Privacy.Retention, config.AppConfig.Privacy.PurgeInterval)
+
+	// Setup signing of verification links
+	signer, err := newSigner()
+	if err != nil {
+		slog.Error("Failed to setup signer", "error", err)
+		os.Exit(1)
+	}
+
+	// Remember visitors' consent to optional cookies and third-party scripts
+	consentManager := consent.NewManager(signer, config.AppConfig.Privacy.PolicyVersion, config.AppConfig.Privacy.ConsentTTL)
+	registerThirdPartyTags()
+
+	// Collect CSP violation reports
+	cspReports, err := csp.NewCollector(filepath.Join(config.AppConfig.Storage.Dir, "csp-reports.json"))
+	if err != nil {
+		slog.Error("Failed to open CSP report storage", "error", err)
+		os.Exit(1)
+	}
+	go cspReports.Run(ctx, config.AppConfig.CSP.SaveInterval)
+
+	// Count page views reported by the page script
+	pageViews, err := analytics.Open(filepath.Join(config.AppConfig.Storage.Dir, "analytics.json"))
+	if err != nil {
+		slog.Error("Failed to open analytics storage", "error", err)
+		os.Exit(1)
+	}
+	go pageViews.Run(ctx, config.AppConfig.Analytics.SaveInterval)
+
+	geo, err := newGeoIP()
+	if err != nil {
+		slog.Error("Failed to open GeoIP database", "error", err)
+		os.Exit(1)
+	}
+
+	// Setup Telegram client
+	var telegramClient *telegram.Client
+	if config.AppConfig.Telegram.Token != "" {
+		telegramClient = telegram.NewClient(
+			config.AppConfig.Telegram.Token,
+			telegram.WithBaseURL(config.AppConfig.Telegram.APIURL),
+			telegram.WithHTTPClient(&http.Client{
+				Timeout:   70 * time.Second,
+				Transport: tracing.Transport(nil, config.AppConfig.Telegram.Token),
+			}),
+		)
 	}
 
+	// Readiness checks, registered once the handler is built
+	checker := health.New(5 * time.Second)
+
 	// Setup handlers
-	h := handler.New(
+	handlerOpts := []handler.HandlerOption{
 		handler.WithTelegramConfig(
-			config.AppConfig.Telegram.Token,
+			telegramClient,
 			config.AppConfig.Telegram.ChatID,
 		),
-	)
-
-	// Create Echo instance
-	e := echo.New()
-	e.HideBanner = true
-	e.HidePort = true
-
-	// Add middleware
-	e.Use(echoMiddleware.Recover())
-
-	// Custom logger that matches slog format and skips healthz and metrics endpoints
-	e.Use(middleware.ConditionalLogger())
-	// Security middlewares
-	e.Use(echoMiddleware.CSRFWithConfig(echoMiddleware.CSRFConfig{
-		TokenLookup:    "form:_csrf",
-		CookieName:     "csrf",
-		CookieMaxAge:   3600,
-		CookieSecure:   true,
-		CookieHTTPOnly: true,
-		CookieSameSite: http.SameSiteStrictMode,
-		ContextKey:     "csrf",
-		Skipper: func(c echo.Context) bool {
-			// Skip CSRF for metrics and health check endpoints
-			path := c.Request().URL.Path
-			return strings.HasPrefix(path, "/healthz") || strings.HasPrefix(path, "/metrics")
-		},
-	}))
-	e.Use(echoMiddleware.SecureWithConfig(echoMiddleware.SecureConfig{
-		XSSProtection:         "1; mode=block",
-		ContentTypeNosniff:    "nosniff",
-		XFrameOptions:         "SAMEORIGIN",
-		HSTSMaxAge:            31536000,
-		HSTSExcludeSubdomains: false,
-		ContentSecurityPolicy: "default-src 'self'; script-src 'self' unpkg.com; style-src 'self' 'unsafe-inline'; img-src 'self' data:; connect-src 'self' api.telegram.org; font-src 'self'; base-uri 'self'; form-action 'self'; frame-ancestors 'self'",
-	}))
-	// Rate limiting
-	e.Use(echoMiddleware.RateLimiterWithConfig(echoMiddleware.RateLimiterConfig{
-		Store: echoMiddleware.NewRateLimiterMemoryStore(20), // 20 requests per second
-		DenyHandler: func(c echo.Context, identifier string, err error) error {
-			return c.JSON(http.StatusTooManyRequests, map[string]string{
-				"error": "too many requests",
-			})
-		},
-	}))
-	// Enable response compression
-	e.Use(echoMiddleware.Gzip())
-	// Cache control for Cloudflare
-	e.Use(middleware.CacheControlMiddleware())
-	// Add minification middleware
-	e.Use(middleware.MinifyMiddleware())
-
-	// Static files handler
-	e.Static("/static", staticDir)
-
-	// Setup Prometheus metrics
-	// Create a custom registry
-	promRegistry := prom.NewRegistry()
-
-	// Register Go runtime metrics
-	promRegistry.MustRegister(collectors.NewGoCollector())
-
-	// Register process metrics
-	promRegistry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
-
-	// Register Echo metrics
-	p := prometheus.NewPrometheus("nestattoboy", nil)
-	p.Use(e)
-
-	// Add health check and metrics endpoints
-	e.GET("/healthz", h.HealthCheckHandler)
-	e.GET("/metrics", echo.WrapHandler(promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{})))
-
-	// Setup application routes
-	e.GET("/", h.HomeHandlerEcho)
-	e.GET("/about", h.AboutHandlerEcho)
-	e.GET("/team", h.TeamHandlerEcho)
-	e.GET("/locations", h.LocationsHandlerEcho)
-	e.GET("/contact", h.ContactHandlerEcho)
-	e.POST("/api/contact", h.ContactSubmitHandlerEcho)
+		handler.WithUploadStore(upload.NewStore(
+			config.AppConfig.Upload.Dir,
+			config.AppConfig.Upload.MaxSize,
+			guard,
+		)),
+		handler.WithSubmissionStore(submissions),
+		handler.WithConfirmationLinks(signer, config.AppConfig.Site.BaseURL, config.AppConfig.Contact.ConfirmationTTL),
+		handler.WithPrivacyPolicy(config.AppConfig.Privacy.PolicyVersion, config.AppConfig.Privacy.Retention),
+		handler.WithCSPReports(cspReports),
+		handler.WithAnalytics(pageViews, geo),
+		handler.WithConsent(consentManager),
+		handler.WithHealth(checker),
+	}
+	var mail mailer.Mailer
+	if smtpCfg := config.AppConfig.SMTP; smtpCfg.Host != "" && smtpCfg.From != "" {
+		mail = mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     smtpCfg.Host,
+			Port:     smtpCfg.Port,
+			Username: smtpCfg.Username,
+			Password: smtpCfg.Password,
+			From:     smtpCfg.From,
+		})
+		handlerOpts = append(handlerOpts, handler.WithMailer(mail, mailer.NewRateLimiter(smtpCfg.RateInterval)))
+	}
+
+	// Site state controlled from the Telegram bot
+	maintenanceMode := &maintenance.Mode{}
+	pageCache := pagecache.New()
+
+	// Purge the CDN edge cache when content or assets change
+	purges := newPurgeBatcher()
+	if purges != nil {
+		go purges.Run(ctx)
+		purgeChangedAssets(manifest, purges)
+	}
+
+	// Setup receiving replies and commands from the Telegram chat
+	if telegramClient != nil && config.AppConfig.Telegram.ChatID != "" {
+		teamBot := bot.New(telegramClient, config.AppConfig.Telegram.ChatID, submissions,
+			bot.WithMailer(mail),
+			bot.WithAdminChats(config.AppConfig.Telegram.AdminChats),
+			bot.WithMaintenance(maintenanceMode),
+			bot.WithPageViews(pageViews),
+			bot.WithPublisher(func(context.Context) error {
+				pageCache.Invalidate()
+				slog.Info("Page cache invalidated", "version", pageCache.Version())
+				if purges != nil {
+					purges.Add(siteURLs(append([]string{"/sitemap.xml"}, cachedPages()...)...)...)
+				}
+				return nil
+			}),
+		)
+		webhook, err := startTelegramReceiver(ctx, telegramClient, teamBot)
+		if err != nil {
+			slog.Error("Failed to start Telegram receiver", "error", err)
+		} else if webhook {
+			handlerOpts = append(handlerOpts, handler.WithTelegramWebhook(teamBot, config.AppConfig.Telegram.WebhookSecret))
+		}
+	}
+
+	h := handler.New(handlerOpts...)
+	addHealthChecks(checker, h, manifest, telegramClient)
+
+	// Take client IPs from forwarding headers of trusted proxies only
+	ipExtractor, err := newIPExtractor()
+	if err != nil {
+		slog.Error("Invalid proxy configuration", "error", err)
+		os.Exit(1)
+	}
+	go ipExtractor.Run(ctx, config.AppConfig.Proxy.CloudflareRefresh)
+
+	e := newRouter(site{
+		handler:     h,
+		manifest:    manifest,
+		maintenance: maintenanceMode,
+		pageCache:   pageCache,
+		consent:     consentManager,
+		rateLimit:   20, // 20 requests per second
+		tls:         tlsEnabled(),
+		ipExtractor: ipExtractor.Extract,
+	})
 
 	// Start server in a goroutine
+	server := newHTTPServer(e)
+	tlsConfig, plainHandler, err := newTLSConfig(ctx)
+	if err != nil {
+		slog.Error("Failed to setup TLS", "mode", config.AppConfig.TLS.Mode, "error", err)
+		os.Exit(1)
+	}
+	server.TLSConfig = tlsConfig
+	listener, err := listen(server.Addr)
+	if err != nil {
+		slog.Error("Failed to listen", "address", server.Addr, "error", err)
+		os.Exit(1)
+	}
 	go func() {
-		port := config.AppConfig.Server.Port
-		address := fmt.Sprintf(":%d", port)
-		slog.Info("Server starting", "port", port)
-		if err := e.Start(address); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			slog.Error("Server error", "error", err)
+		build := version.Get()
+		slog.Info("Server starting", "address", server.Addr, "tls", tlsEnabled(), "version", build.Version, "commit", build.Commit)
+		var serveErr error
+		if tlsConfig != nil {
+			// ServeTLS also enables HTTP/2
+			serveErr = server.ServeTLS(listener, "", "")
+		} else {
+			serveErr = server.Serve(listener)
+		}
+		if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			slog.Error("Server error", "error", serveErr)
 			os.Exit(1)
 		}
 	}()
 
+	// Redirect plain HTTP to HTTPS and answer ACME challenges
+	var redirectServer *http.Server
+	if tlsConfig != nil && config.AppConfig.TLS.RedirectPort > 0 {
+		redirectServer = newRedirectServer(plainHandler)
+		go func() {
+			slog.Info("Redirect server starting", "address", redirectServer.Addr)
+			if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Redirect server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	// Handle graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -161,13 +303,181 @@ func main() {
 
 	slog.Info("Shutting down server...")
 
+	// Fail readiness first, so that load balancers stop sending new requests
+	checker.Shutdown()
+	if delay := config.AppConfig.Server.ShutdownDelay; delay > 0 {
+		slog.Info("Waiting for load balancers to notice the shutdown", "delay", delay.String())
+		time.Sleep(delay)
+	}
+
 	// Create a deadline to wait for
 	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 10*time.Second)
 	defer shutdownCancel()
 
-	if err := e.Shutdown(shutdownCtx); err != nil {
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		slog.Error("Server shutdown error", "error", err)
 	}
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Redirect server shutdown error", "error", err)
+		}
+	}
+
+	// Purge what changed since the last batch, so that the asset state is saved
+	if purges != nil {
+		if err := purges.Flush(shutdownCtx); err != nil {
+			slog.Error("Failed to purge CDN cache", "error", err)
+		}
+	}
+
+	if err := pageViews.Save(time.Now()); err != nil {
+		slog.Error("Failed to save analytics", "error", err)
+	}
+	if err := cspReports.Save(); err != nil {
+		slog.Error("Failed to save CSP reports", "error", err)
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
 
 	slog.Info("Server stopped")
 }
+
+// staticFS returns the static files to serve: the embedded copy, or the
+// directory from configuration when developing
+func staticFS() fs.FS {
+	if dir := config.AppConfig.Static.Dir; dir != "" {
+		slog.Info("Serving static files from disk", "directory", dir)
+		return os.DirFS(dir)
+	}
+	return web.StaticFS()
+}
+
+// newScannerGuard builds the upload malware scanner from configuration
+func newScannerGuard() (*scanner.Guard, error) {
+	cfg := config.AppConfig.Scanner
+
+	policy, err := scanner.ParsePolicy(cfg.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []scanner.GuardOption{scanner.WithPolicy(policy)}
+	if cfg.QuarantineDir != "" {
+		opts = append(opts, scanner.WithQuarantine(scanner.NewQuarantine(cfg.QuarantineDir)))
+	}
+
+	if cfg.Address == "" {
+		return scanner.NewGuard(nil, opts...), nil
+	}
+
+	clamd, err := scanner.NewClamd(cfg.Address, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanner.NewGuard(clamd, opts...), nil
+}
+
+// googleTagHost serves the Google tag script
+const googleTagHost = "https://www.googletagmanager.com"
+
+// registerThirdPartyTags registers the configured third-party scripts, which
+// pages only load for visitors who allowed their category
+func registerThirdPartyTags() {
+	if id := config.AppConfig.Analytics.GoogleTagID; id != "" {
+		setup := templ.Attributes{"data-tag-id": id}
+		if integrity := assets.Integrity("js/gtag.js"); integrity != "" {
+			setup["integrity"] = integrity
+		}
+
+		consent.Register(
+			consent.Tag{Category: consent.Analytics, Src: googleTagHost + "/gtag/js?id=" + url.QueryEscape(id), Attrs: templ.Attributes{"async": true}},
+			consent.Tag{Category: consent.Analytics, Src: assets.URL("js/gtag.js"), Attrs: setup},
+		)
+	}
+}
+
+// newGeoIP opens the configured country database, or returns nil if none is configured
+func newGeoIP() (*geoip.DB, error) {
+	path := config.AppConfig.Analytics.GeoIPDB
+	if path == "" {
+		slog.Info("NESTAT_ANALYTICS_GEOIPDB environment variable not set - page views will not be counted per country")
+		return nil, nil
+	}
+
+	return geoip.Open(path)
+}
+
+// newSigner creates the signer for verification links from the configured secret
+func newSigner() (*signing.Signer, error) {
+	if secret := config.AppConfig.Security.Secret; secret != "" {
+		return signing.New([]byte(secret)), nil
+	}
+
+	key, err := signing.RandomKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return signing.New(key), nil
+}
+
+// newContentSecurityPolicy assembles the enforced and the optional report-only
+// policy from configuration
+func newContentSecurityPolicy() (*csp.Policy, *csp.Policy) {
+	cfg := config.AppConfig.CSP
+
+	policy := csp.New().
+		Add(csp.DefaultSrc, cfg.DefaultSrc...).
+		Add(csp.ScriptSrc, cfg.ScriptSrc...).
+		Add(csp.StyleSrc, cfg.StyleSrc...).
+		Add(csp.ImgSrc, cfg.ImgSrc...).
+		Add(csp.ConnectSrc, cfg.ConnectSrc...).
+		Add(csp.FontSrc, cfg.FontSrc...).
+		Add(csp.BaseURI, cfg.BaseURI...).
+		Add(csp.FormAction, cfg.FormAction...).
+		Add(csp.FrameAncestors, cfg.FrameAncestors...).
+		WithReportURI(cfg.ReportURI)
+
+	// Google Analytics is only loaded with consent, but must be allowed when it is
+	if config.AppConfig.Analytics.GoogleTagID != "" {
+		policy.Add(csp.ScriptSrc, googleTagHost).
+			Add(csp.ImgSrc, googleTagHost, "https://*.google-analytics.com").
+			Add(csp.ConnectSrc, googleTagHost, "https://*.google-analytics.com", "https://*.analytics.google.com")
+	}
+
+	if cfg.ReportOnly == "" {
+		return policy, nil
+	}
+
+	return policy, csp.Parse(cfg.ReportOnly).WithReportURI(cfg.ReportURI)
+}
+
+// startTelegramReceiver starts receiving chat updates in the configured mode.
+// It reports whether updates are expected on the webhook endpoint.
+func startTelegramReceiver(ctx context.Context, client *telegram.Client, b *bot.Bot) (bool, error) {
+	cfg := config.AppConfig.Telegram
+
+	switch cfg.Mode {
+	case "":
+		return false, nil
+	case "poll":
+		slog.Info("Receiving Telegram updates by long polling")
+		go client.Poll(ctx, b.HandleUpdate)
+		return false, nil
+	case "webhook":
+		if cfg.WebhookSecret == "" {
+			return false, errors.New("telegram webhook mode requires NESTAT_TELEGRAM_WEBHOOKSECRET")
+		}
+		url := strings.TrimRight(config.AppConfig.Site.BaseURL, "/") + "/telegram/webhook"
+		if err := client.SetWebhook(ctx, url, cfg.WebhookSecret); err != nil {
+			return false, err
+		}
+		slog.Info("Receiving Telegram updates by webhook", "url", url)
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown telegram mode %q", cfg.Mode)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/config"
+	"github.com/lexfrei/ne-stat-toboy/internal/privacy"
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// newPrivacyCommand returns the "privacy" command for handling personal data requests
+func newPrivacyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "privacy",
+		Short: "Export, erase or purge personal data from contact submissions",
+	}
+
+	var exportEmail, exportOutput string
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print all data stored for an email address as JSON",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := openSubmissions()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if exportOutput != "" {
+				file, err := os.OpenFile(exportOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+				if err != nil {
+					return fmt.Errorf("failed to create export file: %w", err)
+				}
+				defer file.Close()
+				out = file
+			}
+
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(privacy.ExportByEmail(store, exportEmail))
+		},
+	}
+	exportCmd.Flags().StringVar(&exportEmail, "email", "", "Email address of the data subject")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the export to a file instead of stdout")
+	_ = exportCmd.MarkFlagRequired("email")
+
+	var eraseEmail string
+	eraseCmd := &cobra.Command{
+		Use:   "erase",
+		Short: "Delete all data stored for an email address",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := openSubmissions()
+			if err != nil {
+				return err
+			}
+
+			deleted, err := privacy.EraseByEmail(store, eraseEmail)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted %d submission(s)\n", deleted)
+			return nil
+		},
+	}
+	eraseCmd.Flags().StringVar(&eraseEmail, "email", "", "Email address of the data subject")
+	_ = eraseCmd.MarkFlagRequired("email")
+
+	purgeCmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Delete submissions older than the retention period",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := openSubmissions()
+			if err != nil {
+				return err
+			}
+
+			deleted, err := privacy.Purge(store, config.AppConfig.Privacy.Retention, time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted %d submission(s)\n", deleted)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(exportCmd, eraseCmd, purgeCmd)
+	return cmd
+}
+
+// openSubmissions opens the submission storage configured for the server
+func openSubmissions() (*storage.Store, error) {
+	store, err := storage.Open(filepath.Join(config.AppConfig.Storage.Dir, "submissions.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open submission storage: %w", err)
+	}
+	return store, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo-contrib/echoprometheus"
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/lexfrei/ne-stat-toboy/internal/analytics"
+	"github.com/lexfrei/ne-stat-toboy/internal/assets"
+	"github.com/lexfrei/ne-stat-toboy/internal/bot"
+	"github.com/lexfrei/ne-stat-toboy/internal/cdn"
+	"github.com/lexfrei/ne-stat-toboy/internal/config"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+	"github.com/lexfrei/ne-stat-toboy/internal/csp"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+	"github.com/lexfrei/ne-stat-toboy/internal/maintenance"
+	"github.com/lexfrei/ne-stat-toboy/internal/middleware"
+	"github.com/lexfrei/ne-stat-toboy/internal/minify"
+	"github.com/lexfrei/ne-stat-toboy/internal/pagecache"
+	"github.com/lexfrei/ne-stat-toboy/internal/scanner"
+	"github.com/lexfrei/ne-stat-toboy/internal/tracing"
+	"github.com/lexfrei/ne-stat-toboy/internal/version"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"golang.org/x/time/rate"
+)
+
+// site holds what the router serves besides the handlers
+type site struct {
+	handler     *handler.Handler
+	manifest    *assets.Manifest
+	maintenance *maintenance.Mode
+	pageCache   *pagecache.Cache
+	// consent reads visitors' consent choices; the consent banner is
+	// always shown if nil
+	consent *consent.Manager
+	// rateLimit is the number of requests per second allowed per client;
+	// zero disables limiting
+	rateLimit rate.Limit
+	// ipExtractor determines client IPs; the connection peer is used if nil
+	ipExtractor echo.IPExtractor
+	// tls is set when the server terminates TLS itself; HSTS is only sent then
+	tls bool
+}
+
+// buildManifest fingerprints static files for immutable caching and SRI,
+// minifying and precompressing them in memory
+func buildManifest() (*assets.Manifest, error) {
+	manifest, err := assets.Build(staticFS(), "/static",
+		assets.WithTransform(minify.Bytes),
+		assets.WithPrecompression(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	assets.Use(manifest)
+	for _, v := range manifest.Missing() {
+		slog.Warn("Vendored asset not found, falling back to upstream, which csp.scriptsrc must allow - run 'ne-stat-toboy assets vendor'", "asset", v.Name, "url", v.URL)
+	}
+
+	return manifest, nil
+}
+
+// newMetricsRegistry creates the registry of runtime, process and application metrics
+func newMetricsRegistry() *prom.Registry {
+	registry := prom.NewRegistry()
+
+	// Go runtime and process metrics
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	// Contact submissions, notifications and template rendering
+	registry.MustRegister(handler.Collectors()...)
+	// Spam rejections from the Telegram chat
+	registry.MustRegister(bot.Collectors()...)
+	// Rate limit denials
+	registry.MustRegister(middleware.Collectors()...)
+	// Upload scanner
+	registry.MustRegister(scanner.Collectors()...)
+	// CSP violation reports
+	registry.MustRegister(csp.Collectors()...)
+	// Page views
+	registry.MustRegister(analytics.Collectors()...)
+	// Consent decisions
+	registry.MustRegister(consent.Collectors()...)
+	// CDN purges
+	registry.MustRegister(cdn.Collectors()...)
+	// Build information
+	registry.MustRegister(version.Collectors()...)
+
+	return registry
+}
+
+// newRouter sets up the middleware and routes of the website
+func newRouter(s site) *echo.Echo {
+	h := s.handler
+
+	// Create Echo instance
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.IPExtractor = s.ipExtractor
+	if e.IPExtractor == nil {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
+	// Add middleware
+	e.Use(middleware.RequestIDMiddleware())
+	// Server span per request, continuing the caller's W3C trace context
+	e.Use(otelecho.Middleware(tracing.ServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		path := c.Request().URL.Path
+		return middleware.IsMonitoring(path) || strings.HasPrefix(path, "/static/")
+	})))
+	// Access log with request and trace IDs; outside Recover so panics are logged
+	e.Use(middleware.AccessLogMiddleware(config.AppConfig.Log.AccessSampleRate))
+	e.Use(echoMiddleware.Recover())
+
+	// All metrics, including the HTTP ones, are exposed from a single registry
+	registry := newMetricsRegistry()
+	e.Use(echoprometheus.NewMiddlewareWithConfig(echoprometheus.MiddlewareConfig{
+		Namespace:  "nestattoboy",
+		Subsystem:  "http",
+		Registerer: registry,
+		Skipper: func(c echo.Context) bool {
+			return middleware.IsMonitoring(c.Request().URL.Path)
+		},
+		// Unmatched paths are labelled by route, so scanners can't inflate the series
+		DoNotUseRequestPathFor404: true,
+	}))
+
+	// Request body limits, applied before the CSRF check parses forms
+	serverCfg := config.AppConfig.Server
+	e.Use(middleware.BodyLimitMiddleware(serverCfg.BodyLimit, map[string]string{
+		"/consent":        serverCfg.FormBodyLimit,
+		"/api/contact":    serverCfg.FormBodyLimit,
+		"/api/csp-report": serverCfg.FormBodyLimit,
+		"/api/view":       serverCfg.FormBodyLimit,
+		// Leave room for the multipart envelope around the file
+		"/api/upload": strconv.FormatInt(config.AppConfig.Upload.MaxSize+64<<10, 10),
+	}))
+
+	// Let the static export post forms to the API from its own origin
+	exportOrigin := config.AppConfig.Export.Origin
+	if exportOrigin != "" {
+		e.Use(echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
+			Skipper: func(c echo.Context) bool {
+				return !strings.HasPrefix(c.Request().URL.Path, "/api/")
+			},
+			AllowOrigins: []string{exportOrigin},
+			AllowMethods: []string{http.MethodPost},
+			AllowHeaders: []string{
+				echo.HeaderContentType,
+				"HX-Request", "HX-Current-URL", "HX-Target", "HX-Trigger", "HX-Trigger-Name",
+			},
+		}))
+	}
+	// Security middlewares
+	e.Use(echoMiddleware.CSRFWithConfig(echoMiddleware.CSRFConfig{
+		TokenLookup:    "form:_csrf",
+		CookieName:     "csrf",
+		CookieMaxAge:   3600,
+		CookieSecure:   true,
+		CookieHTTPOnly: true,
+		CookieSameSite: http.SameSiteStrictMode,
+		ContextKey:     "csrf",
+		Skipper: func(c echo.Context) bool {
+			// Skip CSRF for metrics and health check endpoints
+			path := c.Request().URL.Path
+			if middleware.IsMonitoring(path) ||
+				strings.HasPrefix(path, "/telegram/") || strings.HasPrefix(path, "/admin/") ||
+				path == "/api/csp-report" || path == "/api/view" || path == "/consent" {
+				return true
+			}
+			// Browsers always send the Origin of cross-origin posts, so the
+			// export's forms are verified by it instead of a token
+			return exportOrigin != "" && strings.HasPrefix(path, "/api/") &&
+				c.Request().Header.Get(echo.HeaderOrigin) == exportOrigin
+		},
+	}))
+	// A zero max age leaves HSTS out
+	hstsMaxAge := 0
+	if s.tls {
+		hstsMaxAge = 31536000
+	}
+	e.Use(echoMiddleware.SecureWithConfig(echoMiddleware.SecureConfig{
+		XSSProtection:         "1; mode=block",
+		ContentTypeNosniff:    "nosniff",
+		XFrameOptions:         "SAMEORIGIN",
+		HSTSMaxAge:            hstsMaxAge,
+		HSTSExcludeSubdomains: false,
+	}))
+	// Content-Security-Policy with per-request script nonces
+	e.Use(middleware.CSPMiddleware(newContentSecurityPolicy()))
+	// Consent choices decide which third-party scripts pages load
+	if s.consent != nil {
+		e.Use(middleware.ConsentMiddleware(s.consent))
+	}
+	// Rate limiting
+	if s.rateLimit > 0 {
+		e.Use(middleware.RateLimitMiddleware(s.rateLimit))
+	}
+	// Enable response compression; static files are served precompressed
+	e.Use(middleware.CompressMiddleware())
+	// Cache control for Cloudflare
+	e.Use(middleware.CacheControlMiddleware())
+	// Add minification middleware
+	e.Use(middleware.MinifyMiddleware())
+	// Maintenance mode switched from the Telegram bot
+	e.Use(middleware.MaintenanceMiddleware(s.maintenance))
+
+	// Static files handler
+	e.GET("/static/*", echo.WrapHandler(s.manifest))
+
+	// Add health check and metrics endpoints
+	e.GET("/healthz", h.HealthCheckHandler)
+	e.GET("/livez", h.HealthCheckHandler)
+	e.GET("/readyz", h.ReadinessHandlerEcho)
+	e.GET("/version", h.VersionHandlerEcho)
+	e.GET("/metrics", echoprometheus.NewHandlerWithConfig(echoprometheus.HandlerConfig{Gatherer: registry}))
+
+	// Setup application routes
+	cached := middleware.PageCacheMiddleware(s.pageCache)
+	e.GET("/", h.HomeHandlerEcho, cached)
+	e.GET("/about", h.AboutHandlerEcho, cached)
+	e.GET("/team", h.TeamHandlerEcho, cached)
+	e.GET("/locations", h.LocationsHandlerEcho, cached)
+	e.GET("/contact", h.ContactHandlerEcho)
+	e.GET("/contact/confirm", h.ContactConfirmHandlerEcho)
+	e.POST("/contact/confirm", h.ContactConfirmSubmitHandlerEcho)
+	e.GET("/privacy", h.PrivacyHandlerEcho, cached)
+	e.GET("/sitemap.xml", h.SitemapHandlerEcho)
+	e.POST("/consent", h.ConsentHandlerEcho)
+	e.POST("/api/contact", h.ContactSubmitHandlerEcho)
+	e.POST("/api/upload", h.UploadHandlerEcho)
+	e.POST("/api/csp-report", h.CSPReportHandlerEcho)
+	e.POST("/api/view", h.ViewBeaconHandlerEcho)
+	e.POST("/telegram/webhook", h.TelegramWebhookHandlerEcho)
+
+	// Admin endpoints for personal data requests
+	if adminCfg := config.AppConfig.Admin; adminCfg.Password != "" {
+		admin := e.Group("/admin", middleware.AdminAuth(adminCfg.Username, adminCfg.Password))
+		admin.POST("/privacy/export", h.AdminPrivacyExportHandlerEcho)
+		admin.POST("/privacy/erase", h.AdminPrivacyEraseHandlerEcho)
+		admin.GET("/csp", h.AdminCSPReportsHandlerEcho)
+		admin.GET("/analytics", h.AdminAnalyticsHandlerEcho)
+	} else {
+		slog.Warn("NESTAT_ADMIN_PASSWORD environment variable not set - admin endpoints will be disabled")
+	}
+
+	return e
+}
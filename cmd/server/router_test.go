@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/config"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+	"github.com/lexfrei/ne-stat-toboy/internal/logging"
+	"github.com/lexfrei/ne-stat-toboy/internal/maintenance"
+	"github.com/lexfrei/ne-stat-toboy/internal/pagecache"
+	"github.com/lexfrei/ne-stat-toboy/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	config.Initialize()
+	os.Exit(m.Run())
+}
+
+// newTestRouter builds the router of a site without external services
+func newTestRouter(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	manifest, err := buildManifest()
+	if err != nil {
+		t.Fatalf("buildManifest: %v", err)
+	}
+	return newRouter(site{
+		handler:     handler.New(),
+		manifest:    manifest,
+		maintenance: &maintenance.Mode{},
+		pageCache:   pagecache.New(),
+	})
+}
+
+func get(t *testing.T, e *echo.Echo, target string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMetricsShareOneRegistry(t *testing.T) {
+	e := newTestRouter(t)
+
+	if rec := get(t, e, "/about", nil); rec.Code != http.StatusOK {
+		t.Fatalf("GET /about = %d", rec.Code)
+	}
+	get(t, e, "/no-such-page", nil)
+
+	rec := get(t, e, "/metrics", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", rec.Code)
+	}
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	metrics := string(body)
+
+	for _, series := range []string{
+		// HTTP metrics of the middleware
+		`nestattoboy_http_requests_total{code="200",host="example.com",method="GET",url="/about"}`,
+		// Unmatched paths are not labelled by the raw path
+		`nestattoboy_http_requests_total{code="404",host="example.com",method="GET",url=""}`,
+		// Domain metrics of the handlers
+		`nestattoboy_template_render_duration_seconds_count{page="about"}`,
+		// Runtime metrics
+		"go_goroutines",
+	} {
+		if !strings.Contains(metrics, series) {
+			t.Errorf("/metrics lacks %s", series)
+		}
+	}
+	if strings.Contains(metrics, "no-such-page") {
+		t.Error("/metrics labels a series with an unmatched path")
+	}
+	if strings.Contains(metrics, `url="/metrics"`) {
+		t.Error("metrics scrapes are counted")
+	}
+}
+
+func TestRequestsAreTraced(t *testing.T) {
+	if _, err := tracing.Setup(t.Context(), tracing.Config{}); err != nil {
+		t.Fatalf("tracing.Setup: %v", err)
+	}
+	spans := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	e := newTestRouter(t)
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	header := http.Header{"Traceparent": {"00-" + traceID + "-" + spanID + "-01"}}
+	if rec := get(t, e, "/about", header); rec.Code != http.StatusOK {
+		t.Fatalf("GET /about = %d", rec.Code)
+	}
+	get(t, e, "/metrics", nil)
+	get(t, e, "/healthz", nil)
+
+	ended := spans.Ended()
+	var server, render sdktrace.ReadOnlySpan
+	for _, span := range ended {
+		switch span.Name() {
+		case "GET /about":
+			server = span
+		case "render about":
+			render = span
+		default:
+			t.Errorf("unexpected span %q", span.Name())
+		}
+	}
+	if server == nil || render == nil {
+		t.Fatalf("spans = %d, want the server and render spans", len(ended))
+	}
+
+	if got := server.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("server span trace = %s, want the caller's %s", got, traceID)
+	}
+	if got := server.Parent().SpanID().String(); got != spanID {
+		t.Errorf("server span parent = %s, want the caller's %s", got, spanID)
+	}
+	if render.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("render span is not a child of the server span")
+	}
+}
+
+func TestRequestIDReachesLogs(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logging.NewContextHandler(slog.NewJSONHandler(&buf, nil))))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	e := newTestRouter(t)
+
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{"proxy ID", "edge-7f3a:42", true},
+		{"no ID", "", false},
+		{"unsafe characters", "id\nlevel=ERROR", false},
+		{"too long", strings.Repeat("a", 65), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			header := http.Header{}
+			if tt.incoming != "" {
+				header.Set(echo.HeaderXRequestID, tt.incoming)
+			}
+			rec := get(t, e, "/no-such-page", header)
+
+			id := rec.Header().Get(echo.HeaderXRequestID)
+			if tt.kept && id != tt.incoming {
+				t.Errorf("X-Request-ID = %q, want the incoming %q", id, tt.incoming)
+			}
+			if !tt.kept && (id == "" || id == tt.incoming) {
+				t.Errorf("X-Request-ID = %q, want a generated ID", id)
+			}
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("want a single access log record, got %q: %v", buf.String(), err)
+			}
+			if record["msg"] != "HTTP Request" || record["request_id"] != id {
+				t.Errorf("access log = %v, want request_id %q", record, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/certs"
+	"github.com/lexfrei/ne-stat-toboy/internal/config"
+	"github.com/lexfrei/ne-stat-toboy/internal/realip"
+	"github.com/lexfrei/ne-stat-toboy/internal/tracing"
+	"golang.org/x/net/netutil"
+)
+
+// newHTTPServer builds the HTTP server from configuration. Its timeouts keep
+// slow clients from holding connections open indefinitely.
+func newHTTPServer(handler http.Handler) *http.Server {
+	cfg := config.AppConfig.Server
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// listen opens the TCP listener of the server, accepting at most the
+// configured number of simultaneous connections
+func listen(address string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := config.AppConfig.Server.MaxConnections; limit > 0 {
+		listener = netutil.LimitListener(listener, limit)
+	}
+	return listener, nil
+}
+
+// newIPExtractor creates the client IP extractor from configuration.
+// Forwarding headers are only believed from trusted proxies, so clients cannot
+// spoof their address to get around rate limits.
+func newIPExtractor() (*realip.Extractor, error) {
+	cfg := config.AppConfig.Proxy
+
+	opts := []realip.Option{
+		realip.WithCloudflareAPI(config.AppConfig.CDN.APIURL),
+		realip.WithHTTPClient(&http.Client{
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport(nil),
+		}),
+	}
+	if cfg.Cloudflare {
+		opts = append(opts, realip.WithCloudflare())
+	}
+	return realip.New(cfg.TrustedCIDRs, opts...)
+}
+
+// TLS modes
+const (
+	tlsModeOff   = ""
+	tlsModeFiles = "files"
+	tlsModeACME  = "acme"
+)
+
+// tlsEnabled reports whether the server terminates TLS itself
+func tlsEnabled() bool {
+	return config.AppConfig.TLS.Mode != tlsModeOff
+}
+
+// newTLSConfig builds the TLS configuration of the configured mode, or nil
+// when TLS is disabled. The returned handler answers plain HTTP requests:
+// ACME challenges and redirects to HTTPS.
+func newTLSConfig(ctx context.Context) (*tls.Config, http.Handler, error) {
+	cfg := config.AppConfig.TLS
+	redirect := certs.RedirectHandler(config.AppConfig.Server.Port, cfg.Domains)
+
+	switch cfg.Mode {
+	case tlsModeOff:
+		return nil, nil, nil
+	case tlsModeFiles:
+		reloader, err := certs.NewReloader(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		go reloader.Run(ctx, cfg.ReloadInterval)
+		return certs.Config(reloader.GetCertificate), redirect, nil
+	case tlsModeACME:
+		manager, err := certs.NewManager(certs.ACMEConfig{
+			Domains:      cfg.Domains,
+			Email:        cfg.Email,
+			DirectoryURL: cfg.DirectoryURL,
+			CAFile:       cfg.CAFile,
+			CacheDir:     cfg.CacheDir,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, manager.HTTPHandler(redirect), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown TLS mode %q", cfg.Mode)
+	}
+}
+
+// newRedirectServer builds the plain HTTP server running next to the HTTPS
+// one; it only redirects and answers ACME challenges, so its timeouts are short
+func newRedirectServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", config.AppConfig.TLS.RedirectPort),
+		Handler:           handler,
+		ReadTimeout:       5 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       time.Minute,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
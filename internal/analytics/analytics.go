@@ -0,0 +1,275 @@
+// Package analytics collects first-party page view statistics. Only daily
+// aggregates are kept: no cookies, addresses or per-visitor records.
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxKeys caps the distinct values of a dimension per day, as beacons are
+// unauthenticated; the rest is counted under Other
+const maxKeys = 200
+
+// retentionDays is how many days of aggregates are kept
+const retentionDays = 400
+
+// Other collects the values of a dimension beyond maxKeys
+const Other = "other"
+
+// View is a single page view, stripped of anything identifying the visitor
+type View struct {
+	Path string
+	// Referrer is the host of the referring site, empty for direct visits
+	Referrer string
+	// Campaign is "source / medium / campaign" from the UTM parameters
+	Campaign string
+	// Device is "desktop", "mobile" or "tablet"
+	Device string
+	// Country is an ISO 3166-1 code, empty if unknown
+	Country string
+}
+
+// Day holds the aggregates of a date (UTC)
+type Day struct {
+	Date      string
+	Views     int64
+	Paths     map[string]int64
+	Referrers map[string]int64
+	Campaigns map[string]int64
+	Devices   map[string]int64
+	Countries map[string]int64
+}
+
+func newDay(date string) *Day {
+	return &Day{
+		Date:      date,
+		Paths:     make(map[string]int64),
+		Referrers: make(map[string]int64),
+		Campaigns: make(map[string]int64),
+		Devices:   make(map[string]int64),
+		Countries: make(map[string]int64),
+	}
+}
+
+// DayViews is the number of page views on a date
+type DayViews struct {
+	Date  string
+	Views int64
+}
+
+// Counter aggregates page views per day (UTC) in memory, optionally
+// persisting them to a file.
+type Counter struct {
+	mu    sync.Mutex
+	path  string
+	dirty bool
+	days  map[string]*Day
+}
+
+// NewCounter creates an empty page view counter kept in memory only
+func NewCounter() *Counter {
+	return &Counter{days: make(map[string]*Day)}
+}
+
+// Open loads the aggregates stored at path, creating the directory if needed
+func Open(path string) (*Counter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create analytics directory: %w", err)
+	}
+
+	c := NewCounter()
+	c.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read analytics: %w", err)
+	}
+
+	var stored []*Day
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode analytics: %w", err)
+	}
+	for _, day := range stored {
+		c.days[day.Date] = day
+	}
+
+	return c, nil
+}
+
+// Record counts a view at the given time
+func (c *Counter) Record(v View, at time.Time) {
+	date := at.UTC().Format(time.DateOnly)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	day, ok := c.days[date]
+	if !ok {
+		day = newDay(date)
+		c.days[date] = day
+	}
+
+	day.Views++
+	increment(day.Paths, v.Path)
+	increment(day.Referrers, v.Referrer)
+	increment(day.Campaigns, v.Campaign)
+	increment(day.Devices, v.Device)
+	increment(day.Countries, v.Country)
+	c.dirty = true
+
+	viewsTotal.WithLabelValues(v.Path, v.Device, v.Country).Inc()
+}
+
+func increment(counts map[string]int64, key string) {
+	if key == "" {
+		return
+	}
+	if _, ok := counts[key]; !ok && len(counts) >= maxKeys {
+		key = Other
+	}
+	counts[key]++
+}
+
+// Daily returns the total views per day for the last days, oldest first
+func (c *Counter) Daily(days int, now time.Time) []DayViews {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]DayViews, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		date := now.UTC().AddDate(0, 0, -i).Format(time.DateOnly)
+		var total int64
+		if day, ok := c.days[date]; ok {
+			total = day.Views
+		}
+		result = append(result, DayViews{Date: date, Views: total})
+	}
+
+	return result
+}
+
+// Summary returns the aggregates of the last days merged into one, under the
+// date of the first of them
+func (c *Counter) Summary(days int, now time.Time) Day {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	from := now.UTC().AddDate(0, 0, -(days - 1)).Format(time.DateOnly)
+	total := newDay(from)
+	for date, day := range c.days {
+		if date < from {
+			continue
+		}
+		total.Views += day.Views
+		merge(total.Paths, day.Paths)
+		merge(total.Referrers, day.Referrers)
+		merge(total.Campaigns, day.Campaigns)
+		merge(total.Devices, day.Devices)
+		merge(total.Countries, day.Countries)
+	}
+
+	return *total
+}
+
+func merge(into, from map[string]int64) {
+	for k, n := range from {
+		into[k] += n
+	}
+}
+
+// Entry is a value of a dimension with its number of views
+type Entry struct {
+	Key   string
+	Views int64
+}
+
+// Top returns the n values with the most views, most viewed first
+func Top(counts map[string]int64, n int) []Entry {
+	entries := make([]Entry, 0, len(counts))
+	for k, views := range counts {
+		entries = append(entries, Entry{Key: k, Views: views})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Views != entries[j].Views {
+			return entries[i].Views > entries[j].Views
+		}
+		return entries[i].Key < entries[j].Key
+	})
+
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// Run saves the aggregates every interval until ctx is cancelled. With a
+// non-positive interval it returns at once, leaving the save to shutdown.
+func (c *Counter) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := c.Save(now); err != nil {
+				slog.Error("Failed to save analytics", "error", err)
+			}
+		}
+	}
+}
+
+// Save drops days past the retention period and writes the aggregates
+// atomically if they changed
+func (c *Counter) Save(now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+
+	cutoff := now.UTC().AddDate(0, 0, -retentionDays).Format(time.DateOnly)
+	list := make([]*Day, 0, len(c.days))
+	for date, day := range c.days {
+		if date < cutoff {
+			delete(c.days, date)
+			continue
+		}
+		list = append(list, day)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Date < list[j].Date })
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to encode analytics: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write analytics: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to replace analytics: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
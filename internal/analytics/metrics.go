@@ -0,0 +1,15 @@
+package analytics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var viewsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "nestattoboy",
+	Subsystem: "analytics",
+	Name:      "page_views_total",
+	Help:      "Number of page views reported by the beacon by path, device class and country.",
+}, []string{"path", "device", "country"})
+
+// Collectors returns the Prometheus collectors of the page view statistics
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{viewsTotal}
+}
@@ -0,0 +1,85 @@
+package analytics
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Device classes derived from the User-Agent
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// ErrInvalidPage is returned for beacons without an absolute page URL
+var ErrInvalidPage = errors.New("invalid page URL")
+
+// botMarkers are User-Agent substrings of crawlers and link previews
+var botMarkers = []string{"bot", "crawl", "spider", "slurp", "preview", "headless", "lighthouse", "curl", "wget"}
+
+// NewView builds a view from what the beacon reports: the page URL and the
+// document referrer. Only the host of the referrer is kept, and referrals
+// from the site itself count as direct visits.
+func NewView(pageURL, referrer, userAgent string) (View, error) {
+	page, err := url.Parse(pageURL)
+	if err != nil || page.Host == "" {
+		return View{}, ErrInvalidPage
+	}
+
+	v := View{
+		Path:   page.Path,
+		Device: DeviceClass(userAgent),
+	}
+	if v.Path == "" {
+		v.Path = "/"
+	}
+
+	if ref, err := url.Parse(referrer); err == nil && ref.Host != "" && !strings.EqualFold(ref.Host, page.Host) {
+		v.Referrer = strings.TrimPrefix(strings.ToLower(ref.Hostname()), "www.")
+	}
+
+	query := page.Query()
+	if source := query.Get("utm_source"); source != "" {
+		v.Campaign = strings.Join([]string{
+			source,
+			orNone(query.Get("utm_medium")),
+			orNone(query.Get("utm_campaign")),
+		}, " / ")
+	}
+
+	return v, nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// DeviceClass tells crawlers, tablets, phones and desktops apart by User-Agent
+func DeviceClass(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return DeviceBot
+	}
+
+	for _, marker := range botMarkers {
+		if strings.Contains(ua, marker) {
+			return DeviceBot
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		(strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "android"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
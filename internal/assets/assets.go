@@ -0,0 +1,273 @@
+// Package assets fingerprints static files so that they can be cached forever
+// and referenced from templates with Subresource Integrity.
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/minify"
+)
+
+// hashLength is the number of hex characters of the content hash put into file names
+const hashLength = 10
+
+// Asset is a static file addressed by its content hash
+type Asset struct {
+	// Name is the logical path relative to the static root, e.g. "css/style.css"
+	Name string
+	// URL is the fingerprinted URL, e.g. "/static/css/style.3f2a9c1b0d.css"
+	URL string
+	// Integrity is the Subresource Integrity value of the file
+	Integrity string
+}
+
+// Manifest maps logical names of static files to their fingerprinted URLs
+// and holds the (transformed) file contents in memory
+type Manifest struct {
+	prefix   string
+	builtAt  time.Time
+	assets   map[string]Asset
+	contents map[string][]byte
+	hashed   map[string]string
+	// variants holds precompressed contents by name and Content-Encoding
+	variants map[string]map[string][]byte
+}
+
+// Transform rewrites the content of a static file before it is fingerprinted
+type Transform func(name string, content []byte) ([]byte, error)
+
+// Option is a functional option for building the manifest
+type Option func(*options)
+
+type options struct {
+	transforms  []Transform
+	precompress bool
+}
+
+// WithTransform applies t to every file, e.g. to minify it in memory
+func WithTransform(t Transform) Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, t)
+	}
+}
+
+// WithPrecompression keeps Brotli, Zstandard and Gzip variants of text assets
+// for clients that accept them. Precompressed siblings in fsys (style.css.br)
+// are used as is when the transforms leave the file unchanged, otherwise
+// the variants are compressed at build time.
+func WithPrecompression() Option {
+	return func(o *options) {
+		o.precompress = true
+	}
+}
+
+// Build reads and fingerprints every file in fsys; URLs are rooted at prefix, e.g. "/static"
+func Build(fsys fs.FS, prefix string, opts ...Option) (*Manifest, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	m := &Manifest{
+		prefix:   strings.TrimRight(prefix, "/"),
+		builtAt:  time.Now(),
+		assets:   make(map[string]Asset),
+		contents: make(map[string][]byte),
+		hashed:   make(map[string]string),
+		variants: make(map[string]map[string][]byte),
+	}
+
+	var names []string
+	files := make(map[string]bool)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		names = append(names, name)
+		files[name] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build asset manifest: %w", err)
+	}
+
+	for _, name := range names {
+		if o.precompress && isSibling(name, files) {
+			continue
+		}
+		if err := m.add(fsys, name, o); err != nil {
+			return nil, fmt.Errorf("failed to build asset manifest: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// add reads, transforms and fingerprints a single file
+func (m *Manifest) add(fsys fs.FS, name string, o options) error {
+	original, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("failed to read asset %s: %w", name, err)
+	}
+
+	data := original
+	for _, t := range o.transforms {
+		if data, err = t(name, data); err != nil {
+			return err
+		}
+	}
+
+	sum := sha256.Sum256(data)
+
+	hashedName := fingerprint(name, hex.EncodeToString(sum[:])[:hashLength])
+	m.assets[name] = Asset{
+		Name:      name,
+		URL:       m.prefix + "/" + hashedName,
+		Integrity: sri(data),
+	}
+	m.contents[name] = data
+	m.hashed[hashedName] = name
+
+	if !o.precompress {
+		return nil
+	}
+
+	variants, err := minify.Compress(name, data)
+	if err != nil {
+		return err
+	}
+	// Siblings were compressed from the file on disk, so they only match untransformed content
+	if bytes.Equal(original, data) {
+		for _, enc := range minify.Encodings {
+			if sibling, err := fs.ReadFile(fsys, name+enc.Ext); err == nil {
+				variants[enc.Name] = sibling
+			}
+		}
+	}
+	if len(variants) > 0 {
+		m.variants[name] = variants
+	}
+
+	return nil
+}
+
+// isSibling reports whether name is a precompressed variant of another file
+func isSibling(name string, files map[string]bool) bool {
+	for _, enc := range minify.Encodings {
+		if base, ok := strings.CutSuffix(name, enc.Ext); ok && files[base] {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the asset with the given logical name
+func (m *Manifest) Lookup(name string) (Asset, bool) {
+	a, ok := m.assets[strings.TrimPrefix(name, "/")]
+	return a, ok
+}
+
+// Len returns the number of assets in the manifest
+func (m *Manifest) Len() int {
+	return len(m.assets)
+}
+
+// Assets returns every asset in the manifest, ordered by name
+func (m *Manifest) Assets() []Asset {
+	list := make([]Asset, 0, len(m.assets))
+	for _, a := range m.assets {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ServeHTTP serves static files by their path below the prefix. Fingerprinted
+// names never change content and are served as immutable; logical names are
+// served as they are, leaving caching to the caller.
+func (m *Manifest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(r.URL.Path, m.prefix)), "/")
+
+	if logical, ok := m.hashed[name]; ok {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("CDN-Cache-Control", "max-age=31536000")
+		w.Header().Set("Cloudflare-CDN-Cache-Control", "max-age=31536000")
+		name = logical
+	}
+
+	content, ok := m.contents[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	etag := path.Base(m.assets[name].URL)
+	if variants, ok := m.variants[name]; ok {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if encoding := minify.Negotiate(r.Header.Get("Accept-Encoding"), variants); encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+			content = variants[encoding]
+			etag += "." + encoding
+		}
+	}
+
+	w.Header().Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, name, m.builtAt, bytes.NewReader(content))
+}
+
+// fingerprint inserts hash before the extension: css/style.css becomes css/style.<hash>.css
+func fingerprint(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+var current atomic.Pointer[Manifest]
+
+// Use makes m the manifest used by URL and Integrity
+func Use(m *Manifest) {
+	current.Store(m)
+}
+
+// URL returns the fingerprinted URL of a static file. Vendored files that
+// have not been fetched resolve to their upstream URL; other unknown names
+// resolve to the plain static path.
+func URL(name string) string {
+	if m := current.Load(); m != nil {
+		if a, ok := m.Lookup(name); ok {
+			return a.URL
+		}
+	}
+
+	if v, ok := vendored(name); ok {
+		return v.URL
+	}
+
+	return "/static/" + strings.TrimPrefix(name, "/")
+}
+
+// Integrity returns the Subresource Integrity value of a static file, or an
+// empty string if the file is not in the manifest. Vendored files that have
+// not been fetched get the integrity of their pinned upstream file.
+func Integrity(name string) string {
+	if m := current.Load(); m != nil {
+		if a, ok := m.Lookup(name); ok {
+			return a.Integrity
+		}
+	}
+
+	if v, ok := vendored(name); ok {
+		return v.Integrity
+	}
+
+	return ""
+}
@@ -0,0 +1,63 @@
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// state maps logical names to fingerprinted URLs, as saved between deploys
+type state map[string]string
+
+// ChangedSince returns the logical names of assets that were added or changed
+// since the state saved at path. Every asset counts as changed if there is no
+// saved state.
+func (m *Manifest) ChangedSince(path string) ([]string, error) {
+	previous := state{}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read asset state: %w", err)
+	default:
+		if err := json.Unmarshal(data, &previous); err != nil {
+			return nil, fmt.Errorf("failed to decode asset state: %w", err)
+		}
+	}
+
+	var changed []string
+	for _, a := range m.Assets() {
+		if previous[a.Name] != a.URL {
+			changed = append(changed, a.Name)
+		}
+	}
+
+	return changed, nil
+}
+
+// SaveState records the fingerprinted URL of every asset at path
+func (m *Manifest) SaveState(path string) error {
+	current := make(state, len(m.assets))
+	for name, a := range m.assets {
+		current[name] = a.URL
+	}
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode asset state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create asset state directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write asset state: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,107 @@
+package assets
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VendoredFile is a third-party file served from our own static directory
+type VendoredFile struct {
+	// Name is the logical path below the static root
+	Name string
+	// URL is the pinned upstream location of the file
+	URL string
+	// Integrity is the SRI hash of the pinned file; downloads that do not
+	// match it are rejected
+	Integrity string
+}
+
+// Vendored lists the third-party front-end files the site depends on
+var Vendored = []VendoredFile{
+	{
+		Name:      "vendor/htmx.min.js",
+		URL:       "https://unpkg.com/htmx.org@1.9.3/dist/htmx.min.js",
+		Integrity: "sha384-lVb3Rd/Ca0AxaoZg5sACe8FJKF0tnUgR2Kd7ehUOG5GCcROv5uBIZsOqovBAcWua",
+	},
+}
+
+// maxVendoredSize limits the size of a downloaded vendored file
+const maxVendoredSize = 5 << 20
+
+// Fetch downloads every vendored file into the static directory dir
+func Fetch(ctx context.Context, client *http.Client, dir string) error {
+	for _, v := range Vendored {
+		if err := fetch(ctx, client, v, filepath.Join(dir, filepath.FromSlash(v.Name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fetch(ctx context.Context, client *http.Client, v VendoredFile, dest string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", v.URL, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", v.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", v.URL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxVendoredSize))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", v.URL, err)
+	}
+	if got := sri(data); got != v.Integrity {
+		return fmt.Errorf("integrity mismatch for %s: got %s, want %s", v.URL, got, v.Integrity)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to create vendor directory: %w", err)
+	}
+	if err := os.WriteFile(dest, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+
+	return nil
+}
+
+// sri returns the sha384 Subresource Integrity value of data
+func sri(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Missing returns the vendored files that are not in the manifest
+func (m *Manifest) Missing() []VendoredFile {
+	var missing []VendoredFile
+	for _, v := range Vendored {
+		if _, ok := m.Lookup(v.Name); !ok {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+func vendored(name string) (VendoredFile, bool) {
+	name = strings.TrimPrefix(name, "/")
+	for _, v := range Vendored {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return VendoredFile{}, false
+}
@@ -0,0 +1,63 @@
+package assets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFetchVerifiesIntegrity(t *testing.T) {
+	const script = "/* htmx */ var htmx = {};"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(script))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		integrity string
+		wantErr   bool
+	}{
+		{"matching", sri([]byte(script)), false},
+		{"tampered", sri([]byte(script + "alert(1)")), true},
+		{"not pinned", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "vendor", "htmx.min.js")
+			v := VendoredFile{Name: "vendor/htmx.min.js", URL: server.URL + "/htmx.min.js", Integrity: tt.integrity}
+
+			err := fetch(context.Background(), server.Client(), v, dest)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "integrity mismatch") {
+					t.Fatalf("fetch = %v, want an integrity mismatch", err)
+				}
+				if _, err := os.Stat(dest); !os.IsNotExist(err) {
+					t.Errorf("unverified file written: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if data, err := os.ReadFile(dest); err != nil || string(data) != script {
+				t.Errorf("written file = %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestVendoredFilesArePinned(t *testing.T) {
+	for _, v := range Vendored {
+		if !strings.HasPrefix(v.Integrity, "sha384-") {
+			t.Errorf("%s has no sha384 integrity pin", v.Name)
+		}
+		if !strings.Contains(v.URL, "@") {
+			t.Errorf("%s is not pinned to a version: %s", v.Name, v.URL)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Package bot processes Telegram updates from the team chat.
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/analytics"
+	"github.com/lexfrei/ne-stat-toboy/internal/mailer"
+	"github.com/lexfrei/ne-stat-toboy/internal/maintenance"
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+)
+
+// Bot routes replies from the team chat back to the visitors who wrote to us
+// and serves admin commands and moderation buttons.
+type Bot struct {
+	client *telegram.Client
+	chatID string
+	store  *storage.Store
+
+	mailer      mailer.Mailer
+	adminChats  map[string]bool
+	maintenance *maintenance.Mode
+	pageViews   *analytics.Counter
+	publish     func(ctx context.Context) error
+}
+
+// Option is a functional option for configuring the bot
+type Option func(*Bot)
+
+// WithMailer enables emailing chat replies to submitters
+func WithMailer(m mailer.Mailer) Option {
+	return func(b *Bot) {
+		b.mailer = m
+	}
+}
+
+// WithAdminChats whitelists the chats allowed to use commands and buttons.
+// Without it only the team chat is allowed.
+func WithAdminChats(chatIDs []string) Option {
+	return func(b *Bot) {
+		for _, id := range chatIDs {
+			if id = strings.TrimSpace(id); id != "" {
+				b.adminChats[id] = true
+			}
+		}
+	}
+}
+
+// WithMaintenance lets /maintenance switch the given mode
+func WithMaintenance(mode *maintenance.Mode) Option {
+	return func(b *Bot) {
+		b.maintenance = mode
+	}
+}
+
+// WithPageViews lets /stats report page views from the counter
+func WithPageViews(counter *analytics.Counter) Option {
+	return func(b *Bot) {
+		b.pageViews = counter
+	}
+}
+
+// WithPublisher sets the action run by /publish
+func WithPublisher(publish func(ctx context.Context) error) Option {
+	return func(b *Bot) {
+		b.publish = publish
+	}
+}
+
+// New creates a new Bot. Without a mailer chat replies are refused.
+func New(client *telegram.Client, chatID string, store *storage.Store, opts ...Option) *Bot {
+	b := &Bot{
+		client:     client,
+		chatID:     chatID,
+		store:      store,
+		adminChats: make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// HandleUpdate processes a single update; updates from other chats are ignored
+func (b *Bot) HandleUpdate(ctx context.Context, update telegram.Update) {
+	if update.CallbackQuery != nil {
+		b.handleCallback(ctx, update.CallbackQuery)
+		return
+	}
+
+	msg := update.Message
+	if msg == nil {
+		return
+	}
+
+	chat := strconv.FormatInt(msg.Chat.ID, 10)
+	switch {
+	case strings.HasPrefix(msg.Text, "/") && b.isAdmin(chat):
+		b.handleCommand(ctx, msg)
+	case msg.ReplyToMessage != nil && chat == b.chatID:
+		b.handleReply(ctx, msg)
+	}
+}
+
+// isAdmin reports whether the chat may use commands and moderation buttons
+func (b *Bot) isAdmin(chatID string) bool {
+	if len(b.adminChats) == 0 {
+		return chatID == b.chatID
+	}
+	return b.adminChats[chatID]
+}
+
+// handleReply emails a team member's reply to the submitter of the referenced notification
+func (b *Bot) handleReply(ctx context.Context, msg *telegram.Message) {
+	sub, err := b.store.FindByTelegramMessage(msg.ReplyToMessage.MessageID)
+	if errors.Is(err, storage.ErrNotFound) {
+		// A reply in the chat that has nothing to do with a submission
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to look up submission for Telegram reply", "error", err)
+		return
+	}
+
+	answer := strings.TrimSpace(msg.Text)
+	if answer == "" {
+		b.reply(ctx, msg, "⚠️ Можно отвечать только текстом.")
+		return
+	}
+
+	if b.mailer == nil {
+		b.reply(ctx, msg, "⚠️ Отправка почты не настроена, ответ не отправлен.")
+		return
+	}
+
+	email, err := mailer.Reply{
+		SubmissionID: sub.ID,
+		Locale:       sub.Locale,
+		Name:         sub.Name,
+		Original:     sub.Message,
+		Answer:       answer,
+	}.Render(ctx, sub.Email)
+	if err == nil {
+		err = b.mailer.Send(ctx, email)
+	}
+	if err != nil {
+		slog.Error("Failed to email Telegram reply", "submission", sub.ID, "error", err)
+		b.reply(ctx, msg, "❌ Не удалось отправить ответ, попробуйте позже.")
+		return
+	}
+
+	status := fmt.Sprintf("✉️ Ответ отправлен на %s", html.EscapeString(sub.Email))
+	if !sub.Confirmed() {
+		status += " (адрес не подтвержден)"
+	}
+	ackID := b.reply(ctx, msg, status)
+
+	_, err = b.store.Update(sub.ID, func(s *storage.Submission) error {
+		s.Thread = append(s.Thread, storage.ThreadEntry{
+			Author: msg.From.DisplayName(),
+			Text:   answer,
+			SentAt: time.Now().UTC(),
+		})
+		// Replies to the team's answer or to our acknowledgement continue the same thread
+		s.TelegramMessageIDs = append(s.TelegramMessageIDs, msg.MessageID)
+		if ackID != 0 {
+			s.TelegramMessageIDs = append(s.TelegramMessageIDs, ackID)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to record reply in submission thread", "submission", sub.ID, "error", err)
+	}
+
+	slog.Info("Telegram reply emailed to submitter", "submission", sub.ID)
+}
+
+// reply answers msg in its chat and returns the ID of the sent message
+func (b *Bot) reply(ctx context.Context, msg *telegram.Message, text string) int64 {
+	sent, err := b.client.SendMessage(ctx, telegram.SendMessageRequest{
+		ChatID:           strconv.FormatInt(msg.Chat.ID, 10),
+		Text:             text,
+		ParseMode:        "HTML",
+		ReplyToMessageID: msg.MessageID,
+	})
+	if err != nil {
+		slog.Error("Failed to send Telegram message", "error", err)
+		return 0
+	}
+	return sent.MessageID
+}
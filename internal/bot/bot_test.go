@@ -0,0 +1,193 @@
+package bot_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/bot"
+	"github.com/lexfrei/ne-stat-toboy/internal/mailer"
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram/telegramtest"
+)
+
+const (
+	teamChat  = 100
+	otherChat = 200
+)
+
+var member = telegram.User{ID: 7, FirstName: "Masha"}
+
+// recordingMailer keeps sent messages instead of delivering them
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type fixture struct {
+	server *telegramtest.Server
+	client *telegram.Client
+	store  *storage.Store
+	mail   *recordingMailer
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	server := telegramtest.NewServer()
+	t.Cleanup(server.Close)
+
+	store, err := storage.Open(filepath.Join(t.TempDir(), "submissions.json"))
+	if err != nil {
+		t.Fatalf("storage.Open: %v", err)
+	}
+
+	return &fixture{
+		server: server,
+		client: telegram.NewClient("123:test-token", telegram.WithBaseURL(server.URL)),
+		store:  store,
+		mail:   &recordingMailer{},
+	}
+}
+
+func (f *fixture) bot(opts ...bot.Option) *bot.Bot {
+	return bot.New(f.client, "100", f.store, append([]bot.Option{bot.WithMailer(f.mail)}, opts...)...)
+}
+
+func TestCommandsAnswerInTheAdminChat(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"/help", "/latest"},
+		{"/help@test_bot", "/latest"},
+		{"/LATEST", "Сообщений пока нет."},
+		{"/unknown", "Неизвестная команда"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			f := newFixture(t)
+			update := f.server.Command(teamChat, member, tt.text)
+			f.bot().HandleUpdate(context.Background(), update)
+
+			sent := f.server.Sent()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sent))
+			}
+			if sent[0].ChatID != "100" || sent[0].ReplyToMessageID != update.Message.MessageID {
+				t.Errorf("reply sent to chat %s message %d, want chat 100 message %d",
+					sent[0].ChatID, sent[0].ReplyToMessageID, update.Message.MessageID)
+			}
+			if !strings.Contains(sent[0].Text, tt.want) {
+				t.Errorf("reply %q does not contain %q", sent[0].Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandsFromOtherChatsAreIgnored(t *testing.T) {
+	f := newFixture(t)
+	f.bot().HandleUpdate(context.Background(), f.server.Command(otherChat, member, "/latest"))
+
+	if sent := f.server.Sent(); len(sent) != 0 {
+		t.Errorf("answered a command from another chat: %+v", sent)
+	}
+}
+
+func TestAdminChatsReplaceTheTeamChat(t *testing.T) {
+	f := newFixture(t)
+	b := f.bot(bot.WithAdminChats([]string{"200"}))
+
+	b.HandleUpdate(context.Background(), f.server.Command(teamChat, member, "/help"))
+	if sent := f.server.Sent(); len(sent) != 0 {
+		t.Fatalf("answered a command from a chat that is not an admin chat: %+v", sent)
+	}
+
+	b.HandleUpdate(context.Background(), f.server.Command(otherChat, member, "/help"))
+	if sent := f.server.Sent(); len(sent) != 1 || sent[0].ChatID != "200" {
+		t.Fatalf("admin chat command answered with %+v", sent)
+	}
+}
+
+func TestRepliesAreEmailedToTheSubmitter(t *testing.T) {
+	f := newFixture(t)
+	sub, err := f.store.Create(storage.Submission{
+		Name:               "Visitor",
+		Email:              "visitor@example.com",
+		Message:            "When is the premiere?",
+		Locale:             "en",
+		TelegramMessageIDs: []int64{42},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	update := f.server.Reply(teamChat, 42, member, "In May!")
+	f.bot().HandleUpdate(context.Background(), update)
+
+	if len(f.mail.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(f.mail.sent))
+	}
+	if email := f.mail.sent[0]; email.To != sub.Email || !strings.Contains(email.Text, "In May!") {
+		t.Errorf("email = %+v", email)
+	}
+
+	sent := f.server.Sent()
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "Ответ отправлен") {
+		t.Fatalf("acknowledgement = %+v", sent)
+	}
+
+	stored, err := f.store.Get(sub.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(stored.Thread) != 1 || stored.Thread[0].Text != "In May!" || stored.Thread[0].Author != "Masha" {
+		t.Errorf("thread = %+v", stored.Thread)
+	}
+	// Replies to the answer continue the thread
+	if !slices.Contains(stored.TelegramMessageIDs, update.Message.MessageID) {
+		t.Errorf("reply message %d not linked to the submission: %v", update.Message.MessageID, stored.TelegramMessageIDs)
+	}
+}
+
+func TestUnrelatedRepliesAreIgnored(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.store.Create(storage.Submission{Email: "visitor@example.com", TelegramMessageIDs: []int64{42}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	b := f.bot()
+
+	// A reply to a message that is not a notification
+	b.HandleUpdate(context.Background(), f.server.Reply(teamChat, 41, member, "ok"))
+	// A reply in another chat
+	b.HandleUpdate(context.Background(), f.server.Reply(otherChat, 42, member, "ok"))
+
+	if len(f.mail.sent) != 0 || len(f.server.Sent()) != 0 {
+		t.Errorf("unrelated replies handled: emails %+v, messages %+v", f.mail.sent, f.server.Sent())
+	}
+}
+
+func TestRepliesWithoutMailerAreRefused(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.store.Create(storage.Submission{Email: "visitor@example.com", TelegramMessageIDs: []int64{42}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	bot.New(f.client, "100", f.store).HandleUpdate(context.Background(), f.server.Reply(teamChat, 42, member, "In May!"))
+
+	sent := f.server.Sent()
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "не настроена") {
+		t.Errorf("reply without mailer answered with %+v", sent)
+	}
+}
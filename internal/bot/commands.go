@@ -0,0 +1,194 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+)
+
+// command handles an admin command and returns the reply text (HTML)
+type command func(b *Bot, ctx context.Context, args []string) string
+
+var commands = map[string]command{
+	"start":       (*Bot).cmdHelp,
+	"help":        (*Bot).cmdHelp,
+	"latest":      (*Bot).cmdLatest,
+	"stats":       (*Bot).cmdStats,
+	"spam":        (*Bot).cmdSpam,
+	"publish":     (*Bot).cmdPublish,
+	"maintenance": (*Bot).cmdMaintenance,
+}
+
+const helpText = `<b>Команды</b>
+/latest — последние сообщения с сайта
+/stats — просмотры и сообщения по дням
+/spam &lt;id&gt; — пометить сообщение как спам
+/publish — опубликовать изменения контента
+/maintenance on|off — режим технических работ`
+
+// statsDays is the number of days reported by /stats
+const statsDays = 7
+
+// latestCount is the number of submissions listed by /latest
+const latestCount = 5
+
+// handleCommand routes "/name args" (or "/name@BotName args") to the command
+func (b *Bot) handleCommand(ctx context.Context, msg *telegram.Message) {
+	fields := strings.Fields(msg.Text)
+	name, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+
+	cmd, ok := commands[strings.ToLower(name)]
+	if !ok {
+		b.reply(ctx, msg, "Неизвестная команда. Список команд: /help")
+		return
+	}
+
+	slog.Info("Telegram admin command", "command", name, "chat", msg.Chat.ID, "user", msg.From.DisplayName())
+	b.reply(ctx, msg, cmd(b, ctx, fields[1:]))
+}
+
+func (b *Bot) cmdHelp(_ context.Context, _ []string) string {
+	return helpText
+}
+
+func (b *Bot) cmdLatest(_ context.Context, _ []string) string {
+	latest := b.store.Latest(latestCount)
+	if len(latest) == 0 {
+		return "Сообщений пока нет."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<b>Последние сообщения</b>\n")
+	for _, sub := range latest {
+		fmt.Fprintf(&sb, "\n<code>%s</code> · %s · %s\n%s · %s\n%s\n",
+			sub.ID,
+			html.EscapeString(sub.Name),
+			html.EscapeString(sub.Email),
+			sub.CreatedAt.Format("2006-01-02 15:04"),
+			statusLabel(sub.Status),
+			html.EscapeString(excerpt(sub.Message, 120)),
+		)
+	}
+	return sb.String()
+}
+
+func (b *Bot) cmdStats(_ context.Context, _ []string) string {
+	now := time.Now()
+	submissions := b.store.CountByDay(statsDays, now)
+
+	views := make(map[string]int64)
+	if b.pageViews != nil {
+		for _, day := range b.pageViews.Daily(statsDays, now) {
+			views[day.Date] = day.Views
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<b>Статистика за неделю</b>\n<code>")
+	sb.WriteString("Дата        Просм.  Сообщ.\n")
+	var totalViews int64
+	var totalSubmissions int
+	for _, day := range submissions {
+		viewsCell := "—"
+		if b.pageViews != nil {
+			viewsCell = fmt.Sprint(views[day.Date])
+		}
+		fmt.Fprintf(&sb, "%s  %6s  %6d\n", day.Date, viewsCell, day.Count)
+		totalViews += views[day.Date]
+		totalSubmissions += day.Count
+	}
+	sb.WriteString("</code>")
+	fmt.Fprintf(&sb, "\nВсего: %d просмотров, %d сообщений", totalViews, totalSubmissions)
+	return sb.String()
+}
+
+func (b *Bot) cmdSpam(_ context.Context, args []string) string {
+	if len(args) != 1 {
+		return "Использование: /spam &lt;id&gt;"
+	}
+
+	return b.setStatus(args[0], storage.StatusSpam)
+}
+
+func (b *Bot) cmdPublish(ctx context.Context, _ []string) string {
+	if b.publish == nil {
+		return "Публикация не настроена."
+	}
+
+	if err := b.publish(ctx); err != nil {
+		slog.Error("Publish from Telegram failed", "error", err)
+		return "❌ Не удалось опубликовать: " + html.EscapeString(err.Error())
+	}
+
+	return "✅ Изменения опубликованы."
+}
+
+func (b *Bot) cmdMaintenance(_ context.Context, args []string) string {
+	if b.maintenance == nil {
+		return "Режим технических работ не настроен."
+	}
+
+	if len(args) == 0 {
+		if b.maintenance.Enabled() {
+			return "Режим технических работ включен."
+		}
+		return "Режим технических работ выключен."
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on":
+		b.maintenance.Set(true)
+		slog.Warn("Maintenance mode enabled from Telegram")
+		return "🛠 Режим технических работ включен."
+	case "off":
+		b.maintenance.Set(false)
+		slog.Info("Maintenance mode disabled from Telegram")
+		return "✅ Режим технических работ выключен."
+	default:
+		return "Использование: /maintenance on|off"
+	}
+}
+
+// setStatus changes the status of a submission and returns a reply for the chat
+func (b *Bot) setStatus(id string, status storage.Status) string {
+	_, err := b.store.SetStatus(id, status)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "Сообщение <code>" + html.EscapeString(id) + "</code> не найдено."
+	}
+	if err != nil {
+		slog.Error("Failed to update submission status", "submission", id, "error", err)
+		return "❌ Не удалось изменить статус."
+	}
+
+	if status == storage.StatusSpam {
+		spamRejectionsTotal.WithLabelValues("command").Inc()
+	}
+	slog.Info("Submission status changed", "submission", id, "status", status)
+	return "Сообщение <code>" + html.EscapeString(id) + "</code>: " + statusLabel(status)
+}
+
+func statusLabel(status storage.Status) string {
+	switch status {
+	case storage.StatusSpam:
+		return "🚫 спам"
+	case storage.StatusResolved:
+		return "✅ решено"
+	default:
+		return "🆕 новое"
+	}
+}
+
+func excerpt(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+)
+
+// Callback actions carried in inline button data as "<action>:<submission id>"
+const (
+	actionSpam    = "spam"
+	actionResolve = "resolve"
+	actionReopen  = "reopen"
+)
+
+// SubmissionKeyboard returns the moderation buttons attached to a submission notification
+func SubmissionKeyboard(submissionID string) *telegram.InlineKeyboardMarkup {
+	return &telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{Text: "🚫 Спам", CallbackData: actionSpam + ":" + submissionID},
+			{Text: "✅ Решено", CallbackData: actionResolve + ":" + submissionID},
+		}},
+	}
+}
+
+// reopenKeyboard replaces the moderation buttons once a submission is handled
+func reopenKeyboard(submissionID string, status storage.Status) *telegram.InlineKeyboardMarkup {
+	return &telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{Text: statusLabel(status) + " · вернуть", CallbackData: actionReopen + ":" + submissionID},
+		}},
+	}
+}
+
+// handleCallback processes a press on a moderation button
+func (b *Bot) handleCallback(ctx context.Context, q *telegram.CallbackQuery) {
+	if q.Message == nil || !b.isAdmin(strconv.FormatInt(q.Message.Chat.ID, 10)) {
+		b.answer(ctx, q, "Нет доступа")
+		return
+	}
+
+	action, id, _ := strings.Cut(q.Data, ":")
+
+	var status storage.Status
+	switch action {
+	case actionSpam:
+		status = storage.StatusSpam
+	case actionResolve:
+		status = storage.StatusResolved
+	case actionReopen:
+		status = storage.StatusNew
+	default:
+		b.answer(ctx, q, "Неизвестное действие")
+		return
+	}
+
+	if _, err := b.store.SetStatus(id, status); err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			slog.Error("Failed to update submission status", "submission", id, "error", err)
+			b.answer(ctx, q, "Не удалось изменить статус")
+			return
+		}
+		b.answer(ctx, q, "Сообщение не найдено")
+		return
+	}
+	if status == storage.StatusSpam {
+		spamRejectionsTotal.WithLabelValues("button").Inc()
+	}
+	slog.Info("Submission status changed", "submission", id, "status", status, "user", q.From.DisplayName())
+
+	keyboard := SubmissionKeyboard(id)
+	if status != storage.StatusNew {
+		keyboard = reopenKeyboard(id, status)
+	}
+	if err := b.client.EditMessageReplyMarkup(ctx, q.Message.Chat.ID, q.Message.MessageID, keyboard); err != nil {
+		slog.Error("Failed to update notification buttons", "error", err)
+	}
+
+	b.answer(ctx, q, statusLabel(status))
+}
+
+func (b *Bot) answer(ctx context.Context, q *telegram.CallbackQuery, text string) {
+	if err := b.client.AnswerCallbackQuery(ctx, q.ID, text); err != nil {
+		slog.Error("Failed to answer Telegram callback", "error", err)
+	}
+}
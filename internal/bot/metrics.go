@@ -0,0 +1,15 @@
+package bot
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var spamRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "nestattoboy",
+	Subsystem: "contact",
+	Name:      "spam_rejections_total",
+	Help:      "Number of contact submissions marked as spam by source.",
+}, []string{"source"})
+
+// Collectors returns the Prometheus collectors of the bot
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{spamRejectionsTotal}
+}
@@ -0,0 +1,165 @@
+// Package cdn purges content from the CDN edge cache after it changes.
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Purger removes URLs from the edge cache
+type Purger interface {
+	Purge(ctx context.Context, urls []string) error
+}
+
+// maxRetryDelay caps the back-off between attempts to purge after failures
+const maxRetryDelay = 10 * time.Minute
+
+// Batcher collects URLs to purge and sends them to the purger in batches.
+// A burst of changes within the window results in a single purge of every
+// distinct URL, which keeps us within the API rate limits of the CDN. URLs
+// that fail to purge are queued again and retried with back-off.
+type Batcher struct {
+	purger     Purger
+	window     time.Duration
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+	order   []string
+	purged  []func()
+	wake    chan struct{}
+}
+
+// NewBatcher creates a batcher that purges at most once per window
+func NewBatcher(purger Purger, window time.Duration) *Batcher {
+	return &Batcher{
+		purger:     purger,
+		window:     window,
+		retryDelay: max(window, time.Second),
+		pending:    make(map[string]struct{}),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Add queues URLs for the next purge
+func (b *Batcher) Add(urls ...string) {
+	b.AddThen(nil, urls...)
+}
+
+// AddThen queues URLs like Add and calls purged once they have all been
+// purged, e.g. to record that a change has reached the edge
+func (b *Batcher) AddThen(purged func(), urls ...string) {
+	b.mu.Lock()
+	b.queue(urls, purged)
+	b.mu.Unlock()
+
+	b.signal()
+}
+
+// queue adds URLs not queued yet; b.mu must be held
+func (b *Batcher) queue(urls []string, purged ...func()) {
+	for _, u := range urls {
+		if _, ok := b.pending[u]; ok {
+			continue
+		}
+		b.pending[u] = struct{}{}
+		b.order = append(b.order, u)
+	}
+	for _, f := range purged {
+		if f != nil {
+			b.purged = append(b.purged, f)
+		}
+	}
+}
+
+func (b *Batcher) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run purges queued URLs until ctx is cancelled, waiting for the window to
+// pass after the first URL is queued so that related changes go out together.
+// After a failure the wait doubles up to maxRetryDelay. What is still queued
+// when ctx is cancelled is flushed before returning.
+func (b *Batcher) Run(ctx context.Context) {
+	delay := b.window
+	for {
+		select {
+		case <-ctx.Done():
+			b.flushOnShutdown(ctx)
+			return
+		case <-b.wake:
+		}
+
+		select {
+		case <-ctx.Done():
+			b.flushOnShutdown(ctx)
+			return
+		case <-time.After(delay):
+		}
+
+		if err := b.Flush(ctx); err != nil {
+			delay = min(max(delay*2, b.retryDelay), maxRetryDelay)
+			slog.Error("Failed to purge CDN cache", "error", err, "retry", delay.String())
+			continue
+		}
+		delay = b.window
+	}
+}
+
+// flushOnShutdown gives the last flush a bounded time of its own, as ctx is
+// already cancelled
+func (b *Batcher) flushOnShutdown(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := b.Flush(ctx); err != nil {
+		slog.Error("Failed to purge CDN cache", "error", err)
+	}
+}
+
+// Flush purges everything queued right away. On failure the URLs are queued
+// again for the next attempt.
+func (b *Batcher) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	urls, purged := b.order, b.purged
+	b.pending = make(map[string]struct{})
+	b.order = nil
+	b.purged = nil
+	b.mu.Unlock()
+
+	if len(urls) == 0 {
+		for _, f := range purged {
+			f()
+		}
+		return nil
+	}
+
+	if err := b.purger.Purge(ctx, urls); err != nil {
+		purgedTotal.WithLabelValues("error").Add(float64(len(urls)))
+
+		b.mu.Lock()
+		// Keep the failed URLs ahead of those queued meanwhile
+		queued, callbacks := b.order, b.purged
+		b.pending = make(map[string]struct{})
+		b.order = nil
+		b.purged = nil
+		b.queue(urls, purged...)
+		b.queue(queued, callbacks...)
+		b.mu.Unlock()
+
+		b.signal()
+		return fmt.Errorf("failed to purge %d URLs: %w", len(urls), err)
+	}
+
+	purgedTotal.WithLabelValues("success").Add(float64(len(urls)))
+	slog.Info("Purged CDN cache", "urls", len(urls))
+	for _, f := range purged {
+		f()
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/cdn/cdntest"
+)
+
+const testToken = "purge-token"
+
+func newPurger(server *cdntest.Server) *Cloudflare {
+	return NewCloudflare("zone", testToken, WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCloudflarePurgeSplitsRequests(t *testing.T) {
+	server := cdntest.NewServer(testToken)
+	defer server.Close()
+
+	urls := make([]string, 65)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/page/%d", i)
+	}
+	if err := newPurger(server).Purge(context.Background(), urls); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 3 || len(requests[0]) != 30 || len(requests[2]) != 5 {
+		t.Fatalf("got %d requests, want batches of 30, 30 and 5", len(requests))
+	}
+	if got := slices.Concat(requests...); !slices.Equal(got, urls) {
+		t.Errorf("purged %v, want %v", got, urls)
+	}
+}
+
+func TestCloudflarePurgeErrors(t *testing.T) {
+	server := cdntest.NewServer(testToken)
+	defer server.Close()
+
+	bad := NewCloudflare("zone", "wrong-token", WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+	if err := bad.Purge(context.Background(), []string{"https://example.com/"}); err == nil {
+		t.Error("Purge succeeded with a wrong token")
+	}
+
+	server.SetFailing(true)
+	if err := newPurger(server).Purge(context.Background(), []string{"https://example.com/"}); err == nil {
+		t.Error("Purge succeeded during an API outage")
+	}
+}
+
+func TestBatcherSendsDistinctURLsOnce(t *testing.T) {
+	server := cdntest.NewServer(testToken)
+	defer server.Close()
+
+	b := NewBatcher(newPurger(server), 50*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+
+	b.Add("https://example.com/", "https://example.com/about")
+	b.Add("https://example.com/about", "https://example.com/team")
+
+	waitFor(t, "the purge", func() bool { return len(server.Requests()) > 0 })
+	time.Sleep(100 * time.Millisecond)
+
+	requests := server.Requests()
+	want := []string{"https://example.com/", "https://example.com/about", "https://example.com/team"}
+	if len(requests) != 1 || !slices.Equal(requests[0], want) {
+		t.Errorf("requests = %v, want one purge of %v", requests, want)
+	}
+}
+
+func TestBatcherRequeuesFailedURLs(t *testing.T) {
+	server := cdntest.NewServer(testToken)
+	defer server.Close()
+	server.SetFailing(true)
+
+	b := NewBatcher(newPurger(server), time.Hour)
+	var purged atomic.Int32
+	b.AddThen(func() { purged.Add(1) }, "https://example.com/static/app.js")
+
+	if err := b.Flush(context.Background()); err == nil {
+		t.Fatal("Flush succeeded during an API outage")
+	}
+	if purged.Load() != 0 {
+		t.Fatal("purged callback called after a failed purge")
+	}
+
+	// Changes queued after the failure go out with the failed ones
+	b.Add("https://example.com/about")
+	server.SetFailing(false)
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	requests := server.Requests()
+	want := []string{"https://example.com/static/app.js", "https://example.com/about"}
+	if len(requests) != 1 || !slices.Equal(requests[0], want) {
+		t.Errorf("requests = %v, want one purge of %v", requests, want)
+	}
+	if purged.Load() != 1 {
+		t.Errorf("purged callback called %d times, want once", purged.Load())
+	}
+}
+
+func TestBatcherRetriesWithBackoff(t *testing.T) {
+	server := cdntest.NewServer(testToken)
+	defer server.Close()
+	server.SetFailing(true)
+
+	b := NewBatcher(newPurger(server), 10*time.Millisecond)
+	b.retryDelay = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+
+	var purged atomic.Bool
+	b.AddThen(func() { purged.Store(true) }, "https://example.com/")
+
+	time.Sleep(100 * time.Millisecond)
+	server.SetFailing(false)
+
+	waitFor(t, "the retried purge", purged.Load)
+	if requests := server.Requests(); len(requests) != 1 || !slices.Equal(requests[0], []string{"https://example.com/"}) {
+		t.Errorf("requests = %v, want the URL purged once", requests)
+	}
+}
+
+func TestBatcherFlushesOnShutdown(t *testing.T) {
+	server := cdntest.NewServer(testToken)
+	defer server.Close()
+
+	b := NewBatcher(newPurger(server), time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Run(ctx)
+	}()
+
+	b.Add("https://example.com/")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+
+	if requests := server.Requests(); len(requests) != 1 {
+		t.Errorf("requests = %v, want the queued URL purged on shutdown", requests)
+	}
+}
@@ -0,0 +1,116 @@
+// Package cdntest provides a fake Cloudflare cache purge and IP ranges API for
+// tests and local development.
+package cdntest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server is a fake Cloudflare API. It records the URLs purged per request.
+type Server struct {
+	*httptest.Server
+
+	token    string
+	mu       sync.Mutex
+	requests [][]string
+	ipRanges []string
+	failing  bool
+}
+
+// NewServer starts a fake API that accepts requests authorized with token
+func NewServer(token string) *Server {
+	s := &Server{token: token, ipRanges: []string{"173.245.48.0/20", "2400:cb00::/32"}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Requests returns the files of every purge request received so far
+func (s *Server) Requests() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.requests...)
+}
+
+// SetFailing makes purge requests fail with a server error, as during an
+// API outage
+func (s *Server) SetFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+// SetIPRanges sets the edge ranges returned by the /ips endpoint, e.g. to
+// make a local proxy pass for the edge
+func (s *Server) SetIPRanges(cidrs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ipRanges = cidrs
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/ips") {
+		s.handleIPs(w)
+		return
+	}
+
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/purge_cache") {
+		reply(w, http.StatusNotFound, 7003, "Could not route to "+r.URL.Path)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		reply(w, http.StatusForbidden, 10000, "Authentication error")
+		return
+	}
+
+	var body struct {
+		Files []string `json:"files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Files) == 0 || len(body.Files) > 30 {
+		reply(w, http.StatusBadRequest, 1012, "Request must contain one of \"purge_everything\", \"files\", \"tags\", \"hosts\" or \"prefixes\"")
+		return
+	}
+
+	s.mu.Lock()
+	failing := s.failing
+	if !failing {
+		s.requests = append(s.requests, body.Files)
+	}
+	s.mu.Unlock()
+
+	if failing {
+		reply(w, http.StatusInternalServerError, 10001, "Internal error")
+		return
+	}
+	reply(w, http.StatusOK, 0, "")
+}
+
+// handleIPs answers like the public /ips endpoint, splitting the ranges by family
+func (s *Server) handleIPs(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := map[string][]string{"ipv4_cidrs": {}, "ipv6_cidrs": {}}
+	for _, cidr := range s.ipRanges {
+		if strings.Contains(cidr, ":") {
+			result["ipv6_cidrs"] = append(result["ipv6_cidrs"], cidr)
+		} else {
+			result["ipv4_cidrs"] = append(result["ipv4_cidrs"], cidr)
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "errors": []any{}, "result": result})
+}
+
+func reply(w http.ResponseWriter, status, code int, message string) {
+	response := map[string]any{"success": status == http.StatusOK, "errors": []any{}}
+	if code != 0 {
+		response["errors"] = []any{map[string]any{"code": code, "message": message}}
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,116 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CloudflareBaseURL is the address of the public Cloudflare API
+const CloudflareBaseURL = "https://api.cloudflare.com/client/v4"
+
+// cloudflareMaxFiles is the number of URLs Cloudflare accepts per purge request
+const cloudflareMaxFiles = 30
+
+// Cloudflare purges URLs from the cache of a Cloudflare zone
+type Cloudflare struct {
+	baseURL    string
+	zoneID     string
+	token      string
+	httpClient *http.Client
+}
+
+// CloudflareOption is a functional option for configuring the Cloudflare purger
+type CloudflareOption func(*Cloudflare)
+
+// WithBaseURL points the purger at another API server, e.g. a local fake
+func WithBaseURL(baseURL string) CloudflareOption {
+	return func(c *Cloudflare) {
+		if baseURL != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API calls
+func WithHTTPClient(httpClient *http.Client) CloudflareOption {
+	return func(c *Cloudflare) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewCloudflare creates a purger for the zone, authenticated by an API token
+// with the Cache Purge permission
+func NewCloudflare(zoneID, token string, opts ...CloudflareOption) *Cloudflare {
+	c := &Cloudflare{
+		baseURL:    CloudflareBaseURL,
+		zoneID:     zoneID,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Purge removes the URLs from the zone cache, splitting them into requests
+// of at most cloudflareMaxFiles
+func (c *Cloudflare) Purge(ctx context.Context, urls []string) error {
+	for start := 0; start < len(urls); start += cloudflareMaxFiles {
+		end := min(start+cloudflareMaxFiles, len(urls))
+		if err := c.purgeFiles(ctx, urls[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cloudflare) purgeFiles(ctx context.Context, files []string) error {
+	payload, err := json.Marshal(map[string][]string{"files": files})
+	if err != nil {
+		return fmt.Errorf("failed to marshal purge request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/zones/%s/purge_cache", c.baseURL, c.zoneID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create purge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare purge request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("cloudflare API error: %s", resp.Status)
+	}
+	if !result.Success || resp.StatusCode != http.StatusOK {
+		messages := make([]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			messages = append(messages, fmt.Sprintf("%d %s", e.Code, e.Message))
+		}
+		return fmt.Errorf("cloudflare API error: %s: %s", resp.Status, strings.Join(messages, "; "))
+	}
+
+	return nil
+}
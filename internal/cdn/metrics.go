@@ -0,0 +1,15 @@
+package cdn
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var purgedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "nestattoboy",
+	Subsystem: "cdn",
+	Name:      "purged_urls_total",
+	Help:      "Number of URLs sent to the CDN for purging by result.",
+}, []string{"result"})
+
+// Collectors returns the Prometheus collectors of the purger
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{purgedTotal}
+}
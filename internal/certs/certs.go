@@ -0,0 +1,203 @@
+// Package certs provides the server's TLS certificates: files on disk that are
+// reloaded when renewed, or certificates issued automatically through ACME.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Config returns the server TLS configuration taking certificates from
+// getCertificate. HTTP/2 is offered first.
+func Config(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: getCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+}
+
+// Reloader serves a certificate and key from disk and reloads them when
+// either file changes, so renewed certificates apply without a restart
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewReloader loads the certificate and key, failing if they are unusable
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Run checks the files for changes every interval until ctx is done. The
+// previous certificate is kept if the new files cannot be loaded, e.g. while
+// only one of them has been replaced. A non-positive interval disables
+// reloading.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.reload()
+			if err != nil {
+				slog.Error("Failed to reload TLS certificate", "cert", r.certFile, "error", err)
+				continue
+			}
+			if reloaded {
+				slog.Info("TLS certificate reloaded", "cert", r.certFile, "expires", r.expiry())
+			}
+		}
+	}
+}
+
+// reload loads the files if either was modified since the last load
+func (r *Reloader) reload() (bool, error) {
+	var modTime time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return false, fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	return true, nil
+}
+
+// expiry returns when the current certificate expires
+func (r *Reloader) expiry() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cert == nil || r.cert.Leaf == nil {
+		return time.Time{}
+	}
+	return r.cert.Leaf.NotAfter
+}
+
+// ACMEConfig selects the ACME server and the certificates requested from it
+type ACMEConfig struct {
+	// Domains that certificates are issued for; other names are refused
+	Domains []string
+	// Email is the account contact
+	Email string
+	// DirectoryURL is the ACME server; Let's Encrypt if empty
+	DirectoryURL string
+	// CAFile is a CA bundle trusted for the ACME server's own certificate
+	CAFile string
+	// CacheDir keeps the account key and certificates across restarts
+	CacheDir string
+}
+
+// NewManager creates the ACME certificate manager. Certificates are requested
+// on the first handshake for a domain and renewed before they expire.
+func NewManager(cfg ACMEConfig) (*autocert.Manager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, errors.New("no domains configured for ACME")
+	}
+
+	client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ACME CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		client.HTTPClient = &http.Client{Transport: transport, Timeout: time.Minute}
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Email:      cfg.Email,
+		Client:     client,
+	}, nil
+}
+
+// RedirectHandler sends plain HTTP requests to the same URL over HTTPS on
+// port. Requests for hosts other than the given ones are refused, unless
+// hosts is empty.
+func RedirectHandler(port int, hosts []string) http.Handler {
+	allowed := make([]string, len(hosts))
+	for i, h := range hosts {
+		allowed[i] = strings.ToLower(h)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" || (len(allowed) > 0 && !slices.Contains(allowed, strings.ToLower(host))) {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case port != 443:
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		case strings.Contains(host, ":"):
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,216 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate with the given serial number
+// and its key, dated modTime
+func writeCert(t *testing.T, certFile, keyFile string, serial int64, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), modTime)
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), modTime)
+}
+
+func writeFile(t *testing.T, name string, data []byte, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+}
+
+func serial(t *testing.T, r *Reloader) int64 {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	if err != nil || cert == nil || cert.Leaf == nil {
+		t.Fatalf("GetCertificate = %v, %v", cert, err)
+	}
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+func TestReloaderPicksUpRenewedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	start := time.Now().Add(-time.Hour)
+	writeCert(t, certFile, keyFile, 1, start)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+	if got := serial(t, r); got != 1 {
+		t.Fatalf("serial = %d, want 1", got)
+	}
+
+	if reloaded, err := r.reload(); err != nil || reloaded {
+		t.Errorf("reload of unchanged files = %v, %v; want false, nil", reloaded, err)
+	}
+
+	// A renewal caught halfway: the certificate does not match the old key
+	renewed := filepath.Join(dir, "renewed")
+	if err := os.Mkdir(renewed, 0o700); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	writeCert(t, filepath.Join(renewed, "cert.pem"), filepath.Join(renewed, "key.pem"), 2, start.Add(time.Minute))
+	pemCert, err := os.ReadFile(filepath.Join(renewed, "cert.pem"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	writeFile(t, certFile, pemCert, start.Add(time.Minute))
+
+	if _, err := r.reload(); err == nil {
+		t.Error("reload accepted a certificate that does not match the key")
+	}
+	if got := serial(t, r); got != 1 {
+		t.Errorf("serial = %d after a failed reload, want the previous 1", got)
+	}
+
+	// The key arrives as well
+	pemKey, err := os.ReadFile(filepath.Join(renewed, "key.pem"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	writeFile(t, keyFile, pemKey, start.Add(2*time.Minute))
+
+	if reloaded, err := r.reload(); err != nil || !reloaded {
+		t.Fatalf("reload of renewed files = %v, %v; want true, nil", reloaded, err)
+	}
+	if got := serial(t, r); got != 2 {
+		t.Errorf("serial = %d, want the renewed 2", got)
+	}
+	if r.expiry().IsZero() {
+		t.Error("expiry of the renewed certificate is unknown")
+	}
+}
+
+func TestNewReloaderFailsOnMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")); err == nil {
+		t.Error("NewReloader succeeded without certificate files")
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		port       int
+		hosts      []string
+		host       string
+		target     string
+		wantStatus int
+		wantURL    string
+	}{
+		{"default port", 443, nil, "example.com", "/films?page=2", http.StatusPermanentRedirect, "https://example.com/films?page=2"},
+		{"custom port", 8443, nil, "example.com:8080", "/", http.StatusPermanentRedirect, "https://example.com:8443/"},
+		{"IPv6 host", 443, nil, "[::1]:80", "/", http.StatusPermanentRedirect, "https://[::1]/"},
+		{"IPv6 host, custom port", 8443, nil, "[::1]:80", "/", http.StatusPermanentRedirect, "https://[::1]:8443/"},
+		{"allowed host", 443, []string{"example.com"}, "EXAMPLE.com", "/about", http.StatusPermanentRedirect, "https://EXAMPLE.com/about"},
+		{"unknown host", 443, []string{"example.com"}, "evil.example", "/", http.StatusNotFound, ""},
+		{"empty host", 443, nil, "", "/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			RedirectHandler(tt.port, tt.hosts).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantURL {
+				t.Errorf("Location = %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestNewManager(t *testing.T) {
+	dir := t.TempDir()
+	caFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+	writeCert(t, caFile, keyFile, 1, time.Now())
+
+	m, err := NewManager(ACMEConfig{
+		Domains:      []string{"example.com"},
+		DirectoryURL: "https://acme.test/directory",
+		CAFile:       caFile,
+		CacheDir:     filepath.Join(dir, "acme"),
+	})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if m.Client.DirectoryURL != "https://acme.test/directory" {
+		t.Errorf("DirectoryURL = %q", m.Client.DirectoryURL)
+	}
+	if m.Client.HTTPClient == nil {
+		t.Error("CA file ignored: ACME client uses the default HTTP client")
+	}
+	if err := m.HostPolicy(t.Context(), "example.com"); err != nil {
+		t.Errorf("HostPolicy refused a configured domain: %v", err)
+	}
+	if err := m.HostPolicy(t.Context(), "other.example"); err == nil {
+		t.Error("HostPolicy accepted a domain that is not configured")
+	}
+}
+
+func TestNewManagerErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  ACMEConfig
+	}{
+		{"no domains", ACMEConfig{CacheDir: dir}},
+		{"missing CA file", ACMEConfig{Domains: []string{"example.com"}, CAFile: filepath.Join(dir, "missing.pem")}},
+		{"CA file without certificates", ACMEConfig{Domains: []string{"example.com"}, CAFile: notPEM}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewManager(tt.cfg); err == nil {
+				t.Error("NewManager succeeded")
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/labstack/gommon/bytes"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -16,11 +18,236 @@ type Config struct {
 	Telegram struct {
 		Token  string
 		ChatID string
+		// APIURL is the Bot API server, overridable for a local fake
+		APIURL string
+		// Mode of receiving replies from the chat: "webhook", "poll" or empty to disable
+		Mode          string
+		WebhookSecret string
+		// AdminChats may use bot commands; defaults to ChatID when empty
+		AdminChats []string
 	}
 	
 	// HTTP server configuration
 	Server struct {
 		Port int
+		// Timeouts of reading a whole request, its headers, writing the
+		// response and keeping an idle connection open
+		ReadTimeout       time.Duration
+		ReadHeaderTimeout time.Duration
+		WriteTimeout      time.Duration
+		IdleTimeout       time.Duration
+		MaxHeaderBytes    int
+		// MaxConnections limits simultaneous connections; zero means no limit
+		MaxConnections int
+		// BodyLimit caps request bodies, e.g. "1M"; uploads are capped by
+		// Upload.MaxSize instead
+		BodyLimit string
+		// FormBodyLimit caps the bodies of form and API posts, e.g. "64K"
+		FormBodyLimit string
+		// ShutdownDelay keeps serving after readiness starts failing, so load
+		// balancers stop routing requests before the listener closes
+		ShutdownDelay time.Duration
+	}
+
+	// Client IP configuration
+	Proxy struct {
+		// TrustedCIDRs are proxies whose X-Forwarded-For entries are believed,
+		// e.g. a load balancer in front of the server
+		TrustedCIDRs []string
+		// Cloudflare trusts the Cloudflare edge and takes the client IP from
+		// its CF-Connecting-IP header
+		Cloudflare bool
+		// CloudflareRefresh is how often Cloudflare's ranges are updated from
+		// its API; zero keeps the built-in list
+		CloudflareRefresh time.Duration
+	}
+
+	// Native TLS configuration; without it TLS is left to the CDN
+	TLS struct {
+		// Mode is "files" for a certificate and key on disk, "acme" for
+		// certificates issued automatically, or empty to serve plain HTTP
+		Mode string
+		// CertFile and KeyFile are reloaded when they change on disk
+		CertFile string
+		KeyFile  string
+		// ReloadInterval is how often the files are checked for changes; zero
+		// disables reloading
+		ReloadInterval time.Duration
+		// Domains that ACME certificates are requested for
+		Domains []string
+		// Email is the ACME account contact, notified about expiring certificates
+		Email string
+		// DirectoryURL is the ACME server, replaceable by a local Pebble for testing
+		DirectoryURL string
+		// CAFile is a CA bundle trusted for the ACME server when it uses a
+		// private certificate, like Pebble does
+		CAFile string
+		// CacheDir keeps the ACME account and certificates across restarts
+		CacheDir string
+		// RedirectPort serves redirects to HTTPS and ACME challenges over
+		// plain HTTP; zero disables it
+		RedirectPort int
+	}
+
+	// Static files configuration
+	Static struct {
+		// Dir serves static files from disk instead of the embedded copy, for development
+		Dir string
+	}
+
+	// Upload configuration
+	Upload struct {
+		Dir     string
+		MaxSize int64
+	}
+
+	// Malware scanner configuration
+	Scanner struct {
+		// Address of clamd: tcp://host:port or unix:///path/to/clamd.sock
+		Address string
+		Timeout time.Duration
+		// Policy is "closed" (reject unscanned files) or "open" (accept them)
+		Policy        string
+		QuarantineDir string
+	}
+
+	// Persistent storage configuration
+	Storage struct {
+		Dir string
+	}
+
+	// Public site configuration
+	Site struct {
+		// BaseURL is used to build absolute links in emails
+		BaseURL string
+	}
+
+	// Security configuration
+	Security struct {
+		// Secret signs verification links; a random one is used if empty
+		Secret string
+	}
+
+	// Outgoing mail configuration
+	SMTP struct {
+		Host     string
+		Port     int
+		Username string
+		Password string
+		From     string
+		// RateInterval is the minimum time between emails to the same address
+		RateInterval time.Duration
+	}
+
+	// Contact form configuration
+	Contact struct {
+		// ConfirmationTTL is how long verification links stay valid
+		ConfirmationTTL time.Duration
+	}
+
+	// Personal data processing configuration
+	Privacy struct {
+		// PolicyVersion is recorded with every consent given in the contact form
+		PolicyVersion string
+		// Retention is how long submissions are kept before they are purged
+		Retention time.Duration
+		// PurgeInterval is how often expired submissions are purged; zero
+		// purges only at start
+		PurgeInterval time.Duration
+		// ConsentTTL is how long a visitor's cookie consent choice is remembered
+		ConsentTTL time.Duration
+	}
+
+	// Content-Security-Policy sources per directive, comma-separated in env;
+	// script nonces are added per request
+	CSP struct {
+		DefaultSrc     []string
+		ScriptSrc      []string
+		StyleSrc       []string
+		ImgSrc         []string
+		ConnectSrc     []string
+		FontSrc        []string
+		BaseURI        []string
+		FormAction     []string
+		FrameAncestors []string
+		// ReportURI receives violation reports; empty disables reporting
+		ReportURI string
+		// ReportOnly is a complete policy sent as Content-Security-Policy-Report-Only,
+		// used to test a tighter policy before enforcing it
+		ReportOnly string
+		// SaveInterval is how often collected violation reports are written
+		// to disk; zero writes them only on shutdown
+		SaveInterval time.Duration
+	}
+
+	// Static site export configuration
+	Export struct {
+		// Dir is where the export is written
+		Dir string
+		// APIURL is the absolute URL of this server that exported forms post to;
+		// the site base URL is used if empty
+		APIURL string
+		// Origin is the origin the export is served from; the API accepts
+		// cross-origin posts from it
+		Origin string
+	}
+
+	// CDN cache purging configuration
+	CDN struct {
+		// ZoneID and APIToken of the Cloudflare zone; purging is disabled if empty
+		ZoneID   string
+		APIToken string
+		// APIURL is the Cloudflare API address, replaceable by a local fake
+		APIURL string
+		// BatchWindow collects changes into a single purge request
+		BatchWindow time.Duration
+	}
+
+	// First-party analytics configuration
+	Analytics struct {
+		// GeoIPDB is a MaxMind country database used to count views per
+		// country; countries are not recorded if empty
+		GeoIPDB string
+		// SaveInterval is how often the daily aggregates are written to disk;
+		// zero writes them only on shutdown
+		SaveInterval time.Duration
+		// GoogleTagID loads Google Analytics for visitors who allow analytics;
+		// disabled if empty
+		GoogleTagID string
+	}
+
+	// Logging configuration
+	Log struct {
+		// Level is the minimum level logged: "debug", "info", "warn" or "error"
+		Level string
+		// Format is "json" or "text"
+		Format string
+		// AccessSampleRate is the fraction of successful requests written to
+		// the access log; failed requests are always logged
+		AccessSampleRate float64
+		// RedactKeys name log attributes holding personal data; their values
+		// are masked. Configured tokens and passwords are always masked.
+		RedactKeys []string
+	}
+
+	// Tracing configuration
+	Tracing struct {
+		// Exporter is "otlp-grpc", "otlp-http", "stdout" or empty to disable tracing
+		Exporter string
+		// Endpoint is the collector address, e.g. localhost:4317; the standard
+		// OTEL_EXPORTER_OTLP_* variables apply if empty
+		Endpoint string
+		// Insecure talks to the collector without TLS
+		Insecure bool
+		// SampleRatio is the fraction of new traces that are recorded
+		SampleRatio float64
+	}
+
+	// Admin endpoints configuration
+	Admin struct {
+		Username string
+		// Password protects /admin with basic auth; admin endpoints are disabled if empty
+		Password string
 	}
 }
 
@@ -38,15 +265,124 @@ func Initialize() {
 
 	// Set defaults
 	viper.SetDefault("server.port", 8080)
+	viper.SetDefault("server.readtimeout", 15*time.Second)
+	viper.SetDefault("server.readheadertimeout", 5*time.Second)
+	viper.SetDefault("server.writetimeout", 60*time.Second)
+	viper.SetDefault("server.idletimeout", 2*time.Minute)
+	viper.SetDefault("server.maxheaderbytes", 64<<10)
+	viper.SetDefault("server.maxconnections", 1000)
+	viper.SetDefault("server.bodylimit", "1M")
+	viper.SetDefault("server.formbodylimit", "64K")
+	viper.SetDefault("server.shutdowndelay", 5*time.Second)
+	viper.SetDefault("proxy.trustedcidrs", []string{})
+	viper.SetDefault("proxy.cloudflare", true)
+	viper.SetDefault("proxy.cloudflarerefresh", 24*time.Hour)
+	viper.SetDefault("tls.mode", "")
+	viper.SetDefault("tls.certfile", "")
+	viper.SetDefault("tls.keyfile", "")
+	viper.SetDefault("tls.reloadinterval", time.Minute)
+	viper.SetDefault("tls.domains", []string{})
+	viper.SetDefault("tls.email", "")
+	viper.SetDefault("tls.directoryurl", "https://acme-v02.api.letsencrypt.org/directory")
+	viper.SetDefault("tls.cafile", "")
+	viper.SetDefault("tls.cachedir", "data/acme")
+	viper.SetDefault("tls.redirectport", 80)
 	viper.SetDefault("telegram.token", "")
 	viper.SetDefault("telegram.chatid", "")
+	viper.SetDefault("telegram.apiurl", "https://api.telegram.org")
+	viper.SetDefault("telegram.mode", "")
+	viper.SetDefault("telegram.webhooksecret", "")
+	viper.SetDefault("telegram.adminchats", []string{})
+	viper.SetDefault("static.dir", "")
+	viper.SetDefault("upload.dir", "data/uploads")
+	viper.SetDefault("upload.maxsize", 10<<20)
+	viper.SetDefault("scanner.address", "")
+	viper.SetDefault("scanner.timeout", 30*time.Second)
+	viper.SetDefault("scanner.policy", "closed")
+	viper.SetDefault("scanner.quarantinedir", "data/quarantine")
+	viper.SetDefault("storage.dir", "data")
+	viper.SetDefault("site.baseurl", "http://localhost:8080")
+	viper.SetDefault("security.secret", "")
+	viper.SetDefault("smtp.host", "")
+	viper.SetDefault("smtp.port", 587)
+	viper.SetDefault("smtp.username", "")
+	viper.SetDefault("smtp.password", "")
+	viper.SetDefault("smtp.from", "")
+	viper.SetDefault("smtp.rateinterval", 10*time.Minute)
+	viper.SetDefault("contact.confirmationttl", 48*time.Hour)
+	viper.SetDefault("privacy.policyversion", "2025-01")
+	viper.SetDefault("privacy.retention", 365*24*time.Hour)
+	viper.SetDefault("privacy.purgeinterval", time.Hour)
+	viper.SetDefault("privacy.consentttl", 180*24*time.Hour)
+	viper.SetDefault("csp.defaultsrc", []string{"'self'"})
+	viper.SetDefault("csp.scriptsrc", []string{"'self'"})
+	viper.SetDefault("csp.stylesrc", []string{"'self'", "'unsafe-inline'"})
+	viper.SetDefault("csp.imgsrc", []string{"'self'", "data:"})
+	viper.SetDefault("csp.connectsrc", []string{"'self'"})
+	viper.SetDefault("csp.fontsrc", []string{"'self'"})
+	viper.SetDefault("csp.baseuri", []string{"'self'"})
+	viper.SetDefault("csp.formaction", []string{"'self'"})
+	viper.SetDefault("csp.frameancestors", []string{"'self'"})
+	viper.SetDefault("csp.reporturi", "/api/csp-report")
+	viper.SetDefault("csp.reportonly", "")
+	viper.SetDefault("csp.saveinterval", time.Minute)
+	viper.SetDefault("export.dir", "dist")
+	viper.SetDefault("export.apiurl", "")
+	viper.SetDefault("export.origin", "")
+	viper.SetDefault("cdn.zoneid", "")
+	viper.SetDefault("cdn.apitoken", "")
+	viper.SetDefault("cdn.apiurl", "https://api.cloudflare.com/client/v4")
+	viper.SetDefault("cdn.batchwindow", 10*time.Second)
+	viper.SetDefault("analytics.geoipdb", "")
+	viper.SetDefault("analytics.saveinterval", time.Minute)
+	viper.SetDefault("analytics.googletagid", "")
+	viper.SetDefault("log.level", "info")
+	viper.SetDefault("log.format", "json")
+	viper.SetDefault("log.accesssamplerate", 1.0)
+	viper.SetDefault("log.redactkeys", []string{"email", "name", "phone"})
+	viper.SetDefault("tracing.exporter", "")
+	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("tracing.insecure", false)
+	viper.SetDefault("tracing.sampleratio", 1.0)
+	viper.SetDefault("admin.username", "admin")
+	viper.SetDefault("admin.password", "")
 
 	// Load config into struct
 	if err := viper.Unmarshal(&AppConfig); err != nil {
 		slog.Error("Failed to unmarshal config", "error", err)
 	}
+}
+
+// Validate checks the values that would otherwise fail only once in use
+func Validate() error {
+	limits := []struct{ key, value string }{
+		{"server.bodylimit", AppConfig.Server.BodyLimit},
+		{"server.formbodylimit", AppConfig.Server.FormBodyLimit},
+	}
+	for _, l := range limits {
+		// Parsed the way echo's body limit middleware parses them
+		size, err := bytes.Parse(l.value)
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid %s %q: want a positive size such as \"64K\", \"1M\" or \"1MiB\"", l.key, l.value)
+		}
+	}
+	return nil
+}
+
+// Secrets returns the configured tokens and passwords, which must never be logged
+func Secrets() []string {
+	return []string{
+		AppConfig.Telegram.Token,
+		AppConfig.Telegram.WebhookSecret,
+		AppConfig.Security.Secret,
+		AppConfig.SMTP.Password,
+		AppConfig.CDN.APIToken,
+		AppConfig.Admin.Password,
+	}
+}
 
-	// Validate required configs
+// WarnMissing logs the optional features disabled by missing configuration
+func WarnMissing() {
 	if AppConfig.Telegram.Token == "" {
 		slog.Warn("NESTAT_TELEGRAM_TOKEN environment variable not set - Telegram notifications will be disabled")
 	}
@@ -54,26 +390,62 @@ func Initialize() {
 	if AppConfig.Telegram.ChatID == "" {
 		slog.Warn("NESTAT_TELEGRAM_CHATID environment variable not set - Telegram notifications will be disabled")
 	}
+
+	if AppConfig.SMTP.Host == "" || AppConfig.SMTP.From == "" {
+		slog.Warn("NESTAT_SMTP_HOST or NESTAT_SMTP_FROM environment variable not set - confirmation emails will be disabled")
+	}
+
+	if AppConfig.Security.Secret == "" {
+		slog.Warn("NESTAT_SECURITY_SECRET environment variable not set - signed links will not survive restarts")
+	}
+
+	if AppConfig.Scanner.Address == "" {
+		slog.Warn("NESTAT_SCANNER_ADDRESS environment variable not set - uploads will be handled by the scanner policy", "policy", AppConfig.Scanner.Policy)
+	}
 }
 
-// InitCommands sets up the CLI commands
+// InitCommands sets up the CLI commands. Flags override the environment.
 func InitCommands() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "ne-stat-toboy",
 		Short: "Web application for the short film 'Не Стать Тобой'",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Running server on port", AppConfig.Server.Port)
-			// Server is started in main.go
+		// Apply the flags given on the command line
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			if err := viper.Unmarshal(&AppConfig); err != nil {
+				return fmt.Errorf("failed to unmarshal config: %w", err)
+			}
+			return Validate()
 		},
 	}
 
 	// Add flags
-	rootCmd.PersistentFlags().IntVarP(&AppConfig.Server.Port, "port", "p", 8080, "Port to listen on")
-	
+	flags := rootCmd.PersistentFlags()
+	flags.IntP("port", "p", 8080, "Port to listen on")
+	flags.Duration("read-timeout", 15*time.Second, "Maximum duration for reading a whole request")
+	flags.Duration("read-header-timeout", 5*time.Second, "Maximum duration for reading request headers")
+	flags.Duration("write-timeout", 60*time.Second, "Maximum duration for writing a response")
+	flags.Duration("idle-timeout", 2*time.Minute, "Maximum duration a keep-alive connection stays idle")
+	flags.Int("max-header-bytes", 64<<10, "Maximum size of request headers in bytes")
+	flags.Int("max-connections", 1000, "Maximum number of simultaneous connections, 0 for no limit")
+	flags.String("body-limit", "1M", "Maximum request body size, e.g. 1M")
+	flags.String("form-body-limit", "64K", "Maximum body size of form and API posts, e.g. 64K")
+
 	// Bind flags to viper
-	if err := viper.BindPFlag("server.port", rootCmd.PersistentFlags().Lookup("port")); err != nil {
-		slog.Error("Failed to bind flag", "error", err)
+	for key, flag := range map[string]string{
+		"server.port":              "port",
+		"server.readtimeout":       "read-timeout",
+		"server.readheadertimeout": "read-header-timeout",
+		"server.writetimeout":      "write-timeout",
+		"server.idletimeout":       "idle-timeout",
+		"server.maxheaderbytes":    "max-header-bytes",
+		"server.maxconnections":    "max-connections",
+		"server.bodylimit":         "body-limit",
+		"server.formbodylimit":     "form-body-limit",
+	} {
+		if err := viper.BindPFlag(key, flags.Lookup(flag)); err != nil {
+			slog.Error("Failed to bind flag", "flag", flag, "error", err)
+		}
 	}
 
 	return rootCmd
-}
\ No newline at end of file
+}
@@ -0,0 +1,162 @@
+// Package consent records visitors' choices about optional cookies and
+// third-party scripts, per category, in a signed cookie.
+package consent
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/signing"
+)
+
+// CookieName is the name of the cookie holding the signed choice
+const CookieName = "consent"
+
+// Optional categories a visitor can allow; strictly necessary cookies need no consent
+const (
+	Analytics = "analytics"
+	Marketing = "marketing"
+)
+
+// Categories lists the optional categories in display order
+var Categories = []string{Analytics, Marketing}
+
+// Choice is a visitor's decision on the optional categories
+type Choice struct {
+	// Given reports whether the visitor decided at all
+	Given bool
+	// Allowed lists the allowed categories
+	Allowed []string
+}
+
+// Allows reports whether the category is allowed
+func (c Choice) Allows(category string) bool {
+	return c.Given && slices.Contains(c.Allowed, category)
+}
+
+// Key identifies the choice among all possible ones, e.g. for cache keys
+func (c Choice) Key() string {
+	if !c.Given {
+		return "none"
+	}
+	if len(c.Allowed) == 0 {
+		return "necessary"
+	}
+	return strings.Join(c.Allowed, "+")
+}
+
+// NewChoice makes a given choice allowing the known categories among allowed
+func NewChoice(allowed ...string) Choice {
+	c := Choice{Given: true}
+	for _, category := range Categories {
+		if slices.Contains(allowed, category) {
+			c.Allowed = append(c.Allowed, category)
+		}
+	}
+	return c
+}
+
+// Manager reads and writes the consent cookie. Choices made under another
+// policy version are ignored, so visitors are asked again when it changes.
+type Manager struct {
+	signer  *signing.Signer
+	version string
+	maxAge  time.Duration
+}
+
+// NewManager creates a manager signing cookies with a key derived from signer
+// that stay valid for maxAge
+func NewManager(signer *signing.Signer, version string, maxAge time.Duration) *Manager {
+	return &Manager{signer: signer.For("consent"), version: version, maxAge: maxAge}
+}
+
+// Read returns the choice stored in the request cookie, or an empty choice
+// if there is none or it is invalid, expired or made under another version
+func (m *Manager) Read(r *http.Request) Choice {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return Choice{}
+	}
+
+	value, err := m.signer.Verify(cookie.Value, time.Now())
+	if err != nil {
+		return Choice{}
+	}
+
+	version, allowed, ok := strings.Cut(value, "|")
+	if !ok || version != m.version {
+		return Choice{}
+	}
+
+	if allowed == "" {
+		return NewChoice()
+	}
+	return NewChoice(strings.Split(allowed, ",")...)
+}
+
+// Version returns the policy version choices are recorded under
+func (m *Manager) Version() string {
+	return m.version
+}
+
+// Cookie returns the cookie storing the choice. Scripts can read it to apply
+// the choice on shared pages; it holds nothing secret and is signed.
+func (m *Manager) Cookie(c Choice, now time.Time) *http.Cookie {
+	value := m.version + "|" + strings.Join(c.Allowed, ",")
+
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    m.signer.Sign(value, now.Add(m.maxAge)),
+		Path:     "/",
+		MaxAge:   int(m.maxAge.Seconds()),
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+type (
+	contextKey struct{}
+	versionKey struct{}
+)
+
+// WithChoice returns a context carrying the visitor's choice
+func WithChoice(ctx context.Context, c Choice) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the visitor's choice carried by ctx
+func FromContext(ctx context.Context) Choice {
+	c, _ := ctx.Value(contextKey{}).(Choice)
+	return c
+}
+
+// WithVersion returns a context carrying the policy version, for pages that
+// leave reading the choice to a script
+func WithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// VersionFromContext returns the policy version carried by ctx
+func VersionFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(versionKey{}).(string)
+	return v
+}
+
+// Allowed reports whether the visitor allowed the category
+func Allowed(ctx context.Context, category string) bool {
+	return FromContext(ctx).Allows(category)
+}
+
+// Record counts the decision on every category for reporting
+func Record(c Choice) {
+	for _, category := range Categories {
+		decision := "denied"
+		if c.Allows(category) {
+			decision = "granted"
+		}
+		decisionsTotal.WithLabelValues(category, decision).Inc()
+	}
+}
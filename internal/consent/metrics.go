@@ -0,0 +1,15 @@
+package consent
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var decisionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "nestattoboy",
+	Subsystem: "consent",
+	Name:      "decisions_total",
+	Help:      "Number of consent decisions by category and decision.",
+}, []string{"category", "decision"})
+
+// Collectors returns the Prometheus collectors of the consent decisions
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{decisionsTotal}
+}
@@ -0,0 +1,51 @@
+package consent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/a-h/templ"
+)
+
+// Tag is a third-party script loaded only with consent to its category
+type Tag struct {
+	Category string
+	Src      string
+	Attrs    templ.Attributes
+}
+
+var (
+	tagsMu sync.RWMutex
+	tags   []Tag
+)
+
+// Register adds tags to be emitted on pages whose visitor allowed their category
+func Register(t ...Tag) {
+	tagsMu.Lock()
+	defer tagsMu.Unlock()
+	tags = append(tags, t...)
+}
+
+// Personalized reports whether pages depend on the visitor's choice, which
+// is the case once tags are registered. Otherwise every visitor gets the same
+// page, with the banner left to a script, so shared caches can keep it.
+func Personalized() bool {
+	tagsMu.RLock()
+	defer tagsMu.RUnlock()
+	return len(tags) > 0
+}
+
+// Tags returns the registered tags the visitor allowed, in registration order
+func Tags(ctx context.Context) []Tag {
+	tagsMu.RLock()
+	defer tagsMu.RUnlock()
+
+	choice := FromContext(ctx)
+	var allowed []Tag
+	for _, t := range tags {
+		if choice.Allows(t.Category) {
+			allowed = append(allowed, t)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,15 @@
+package csp
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var violationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "nestattoboy",
+	Subsystem: "csp",
+	Name:      "violations_total",
+	Help:      "Number of reported CSP violations by directive, blocked origin and disposition.",
+}, []string{"directive", "blocked_uri", "disposition"})
+
+// Collectors returns the Prometheus collectors of the violation reports
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{violationsTotal}
+}
@@ -0,0 +1,323 @@
+package csp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Content types of violation reports sent by browsers
+const (
+	// ContentTypeReport is the legacy report-uri format
+	ContentTypeReport = "application/csp-report"
+	// ContentTypeReports is the Reporting API (report-to) format
+	ContentTypeReports = "application/reports+json"
+)
+
+// ReportEndpointName is the Reporting API endpoint name used in report-to
+const ReportEndpointName = "csp-endpoint"
+
+// maxViolations bounds the number of distinct violations kept by a collector
+const maxViolations = 1000
+
+// ErrUnsupportedReport is returned for payloads that are not CSP reports
+var ErrUnsupportedReport = errors.New("unsupported report content type")
+
+// Violation is a deduplicated CSP violation
+type Violation struct {
+	Directive   string    `json:"directive"`
+	BlockedURI  string    `json:"blocked_uri"`
+	DocumentURI string    `json:"document_uri"`
+	SourceFile  string    `json:"source_file,omitempty"`
+	Line        int       `json:"line,omitempty"`
+	ReportOnly  bool      `json:"report_only"`
+	Count       int64     `json:"count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// key identifies duplicates of the same violation
+func (v Violation) key() string {
+	return fmt.Sprintf("%s|%s|%s|%s|%d|%t", v.Directive, v.BlockedURI, v.DocumentURI, v.SourceFile, v.Line, v.ReportOnly)
+}
+
+// legacyReport is the body of an application/csp-report request
+type legacyReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		BlockedURI         string `json:"blocked-uri"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		Disposition        string `json:"disposition"`
+	} `json:"csp-report"`
+}
+
+// apiReport is a single report of an application/reports+json request
+type apiReport struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		BlockedURL         string `json:"blockedURL"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		Disposition        string `json:"disposition"`
+	} `json:"body"`
+}
+
+// ParseReports decodes the violations from a report request body
+func ParseReports(contentType string, body []byte) ([]Violation, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedReport
+	}
+
+	switch mediaType {
+	case ContentTypeReport:
+		var r legacyReport
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, fmt.Errorf("failed to decode CSP report: %w", err)
+		}
+		directive := r.Report.EffectiveDirective
+		if directive == "" {
+			directive, _, _ = strings.Cut(r.Report.ViolatedDirective, " ")
+		}
+		return []Violation{{
+			Directive:   directive,
+			BlockedURI:  r.Report.BlockedURI,
+			DocumentURI: r.Report.DocumentURI,
+			SourceFile:  r.Report.SourceFile,
+			Line:        r.Report.LineNumber,
+			ReportOnly:  r.Report.Disposition == "report",
+		}}, nil
+	case ContentTypeReports:
+		var reports []apiReport
+		if err := json.Unmarshal(body, &reports); err != nil {
+			return nil, fmt.Errorf("failed to decode reports: %w", err)
+		}
+		violations := make([]Violation, 0, len(reports))
+		for _, r := range reports {
+			if r.Type != "csp-violation" {
+				continue
+			}
+			documentURI := r.Body.DocumentURL
+			if documentURI == "" {
+				documentURI = r.URL
+			}
+			violations = append(violations, Violation{
+				Directive:   r.Body.EffectiveDirective,
+				BlockedURI:  r.Body.BlockedURL,
+				DocumentURI: documentURI,
+				SourceFile:  r.Body.SourceFile,
+				Line:        r.Body.LineNumber,
+				ReportOnly:  r.Body.Disposition == "report",
+			})
+		}
+		return violations, nil
+	default:
+		return nil, ErrUnsupportedReport
+	}
+}
+
+// Collector deduplicates violations and keeps them in a JSON file
+type Collector struct {
+	mu         sync.Mutex
+	path       string
+	violations map[string]*Violation
+	dirty      bool
+}
+
+// NewCollector loads the violations stored at path, creating the directory if needed
+func NewCollector(path string) (*Collector, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create CSP report directory: %w", err)
+	}
+
+	c := &Collector{
+		path:       path,
+		violations: make(map[string]*Violation),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSP reports: %w", err)
+	}
+
+	var stored []Violation
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode CSP reports: %w", err)
+	}
+	for i := range stored {
+		c.violations[stored[i].key()] = &stored[i]
+	}
+
+	return c, nil
+}
+
+// Record counts a violation, merging it with earlier reports of the same one.
+// Reports are unauthenticated, so once maxViolations distinct entries are
+// kept a new one replaces the least reported.
+func (c *Collector) Record(v Violation, at time.Time) {
+	v.BlockedURI = normalizeBlockedURI(v.BlockedURI)
+	v.DocumentURI = stripQuery(v.DocumentURI)
+	v.SourceFile = stripQuery(v.SourceFile)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, ok := c.violations[v.key()]; ok {
+		existing.Count++
+		existing.LastSeen = at
+	} else {
+		if len(c.violations) >= maxViolations {
+			c.evict()
+		}
+		v.Count = 1
+		v.FirstSeen = at
+		v.LastSeen = at
+		c.violations[v.key()] = &v
+	}
+	c.dirty = true
+
+	violationsTotal.WithLabelValues(v.Directive, v.BlockedURI, disposition(v)).Inc()
+}
+
+// evict drops the least reported violation, the oldest of equally reported
+// ones, together with its metric series unless another violation shares it;
+// the caller must hold mu
+func (c *Collector) evict() {
+	var victim *Violation
+	for _, v := range c.violations {
+		if victim == nil || v.Count < victim.Count ||
+			v.Count == victim.Count && v.LastSeen.Before(victim.LastSeen) {
+			victim = v
+		}
+	}
+	if victim == nil {
+		return
+	}
+	delete(c.violations, victim.key())
+
+	for _, v := range c.violations {
+		if v.Directive == victim.Directive && v.BlockedURI == victim.BlockedURI && v.ReportOnly == victim.ReportOnly {
+			return
+		}
+	}
+	violationsTotal.DeleteLabelValues(victim.Directive, victim.BlockedURI, disposition(*victim))
+}
+
+// disposition is the metric label of a violation
+func disposition(v Violation) string {
+	if v.ReportOnly {
+		return "report"
+	}
+	return "enforce"
+}
+
+// List returns the violations, most recently seen first
+func (c *Collector) List() []Violation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := make([]Violation, 0, len(c.violations))
+	for _, v := range c.violations {
+		list = append(list, *v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastSeen.After(list[j].LastSeen)
+	})
+	return list
+}
+
+// Run saves the violations every interval until ctx is cancelled. With a
+// non-positive interval it returns at once, leaving the save to shutdown.
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Save(); err != nil {
+				slog.Error("Failed to save CSP reports", "error", err)
+			}
+		}
+	}
+}
+
+// Save writes the violations atomically if they changed
+func (c *Collector) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	list := make([]Violation, 0, len(c.violations))
+	for _, v := range c.violations {
+		list = append(list, *v)
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode CSP reports: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write CSP reports: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to replace CSP reports: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// normalizeBlockedURI reduces a blocked URL to its origin so that reports
+// for the same host are grouped. Keywords such as "inline" and "eval" and
+// scheme-only sources such as "data" are kept as is.
+func normalizeBlockedURI(raw string) string {
+	if raw == "" {
+		return "none"
+	}
+
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return u.Scheme + "://" + u.Host
+	}
+
+	scheme, _, _ := strings.Cut(raw, ":")
+	return scheme
+}
+
+// stripQuery drops the query and fragment, which may carry personal data
+func stripQuery(raw string) string {
+	if i := strings.IndexAny(raw, "?#"); i >= 0 {
+		return raw[:i]
+	}
+	return raw
+}
@@ -0,0 +1,146 @@
+package csp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseReports(t *testing.T) {
+	legacy := `{"csp-report":{"document-uri":"https://example.com/films?id=1","violated-directive":"script-src-elem 'self'","blocked-uri":"https://evil.example/x.js","disposition":"enforce"}}`
+	violations, err := ParseReports("application/csp-report; charset=utf-8", []byte(legacy))
+	if err != nil {
+		t.Fatalf("ParseReports legacy: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Directive != "script-src-elem" || violations[0].ReportOnly {
+		t.Errorf("legacy violations = %+v", violations)
+	}
+
+	reports := `[
+		{"type":"csp-violation","url":"https://example.com/","body":{"effectiveDirective":"img-src","blockedURL":"data","disposition":"report"}},
+		{"type":"deprecation","url":"https://example.com/","body":{}}
+	]`
+	violations, err = ParseReports(ContentTypeReports, []byte(reports))
+	if err != nil {
+		t.Fatalf("ParseReports reports: %v", err)
+	}
+	if len(violations) != 1 || violations[0].DocumentURI != "https://example.com/" || !violations[0].ReportOnly {
+		t.Errorf("Reporting API violations = %+v", violations)
+	}
+
+	if _, err := ParseReports("application/json", []byte(legacy)); err != ErrUnsupportedReport {
+		t.Errorf("ParseReports of JSON = %v, want ErrUnsupportedReport", err)
+	}
+}
+
+func TestRecordMergesDuplicates(t *testing.T) {
+	c, err := NewCollector(filepath.Join(t.TempDir(), "csp-reports.json"))
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+
+	first := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	c.Record(Violation{Directive: "script-src", BlockedURI: "https://cdn.example/a.js?v=1", DocumentURI: "/films?email=me@example.com"}, first)
+	c.Record(Violation{Directive: "script-src", BlockedURI: "https://cdn.example/b.js", DocumentURI: "/films#top"}, first.Add(time.Minute))
+
+	list := c.List()
+	if len(list) != 1 {
+		t.Fatalf("List = %+v, want one merged violation", list)
+	}
+	v := list[0]
+	if v.BlockedURI != "https://cdn.example" || v.DocumentURI != "/films" {
+		t.Errorf("violation not normalized: %+v", v)
+	}
+	if v.Count != 2 || !v.FirstSeen.Equal(first) || !v.LastSeen.Equal(first.Add(time.Minute)) {
+		t.Errorf("violation = %+v, want two reports", v)
+	}
+}
+
+func TestRecordEvictsTheLeastReported(t *testing.T) {
+	violationsTotal.Reset()
+	c, err := NewCollector(filepath.Join(t.TempDir(), "csp-reports.json"))
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	real := Violation{Directive: "eval-test", BlockedURI: "eval", DocumentURI: "/films"}
+	c.Record(real, start)
+	c.Record(real, start)
+
+	// A flood of distinct bogus reports
+	for i := range maxViolations + 50 {
+		c.Record(Violation{Directive: "eval-test", BlockedURI: fmt.Sprintf("https://h%d.example", i), DocumentURI: "/"}, start.Add(time.Duration(i)*time.Second))
+	}
+
+	list := c.List()
+	if len(list) != maxViolations {
+		t.Fatalf("collector keeps %d violations, want %d", len(list), maxViolations)
+	}
+	var kept bool
+	for _, v := range list {
+		if v.BlockedURI == "eval" {
+			kept = v.Count == 2
+		}
+		if v.BlockedURI == "https://h0.example" {
+			t.Error("oldest single report kept over newer ones")
+		}
+	}
+	if !kept {
+		t.Error("repeated violation evicted by single reports")
+	}
+
+	series := testutil.CollectAndCount(violationsTotal, "nestattoboy_csp_violations_total")
+	if series > maxViolations {
+		t.Errorf("%d metric series after eviction, want at most %d", series, maxViolations)
+	}
+}
+
+func TestSaveWritesOnlyChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "csp-reports.json")
+	c, err := NewCollector(path)
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	c.Record(Violation{Directive: "img-src", BlockedURI: "data:", DocumentURI: "/"}, at)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Record wrote the file: %v", err)
+	}
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	// Nothing changed: the file is left alone
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("unchanged reports written again (first write %v)", info.ModTime())
+	}
+
+	c.Record(Violation{Directive: "img-src", BlockedURI: "data:", DocumentURI: "/"}, at.Add(time.Hour))
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	reopened, err := NewCollector(path)
+	if err != nil {
+		t.Fatalf("NewCollector: %v", err)
+	}
+	if list := reopened.List(); len(list) != 1 || list[0].Count != 2 || list[0].BlockedURI != "data" {
+		t.Errorf("reloaded violations = %+v", list)
+	}
+}
@@ -0,0 +1,66 @@
+// Package export renders the public site into static files for any static host.
+package export
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Result describes a single exported file
+type Result struct {
+	// URL is the path the file was rendered from
+	URL string
+	// File is the path of the file relative to the output directory
+	File string
+	Size int
+}
+
+// FilePath maps a URL path to the file a static host serves for it: pages
+// become directory indexes so that links without extensions keep working
+func FilePath(urlPath string) string {
+	clean := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if clean == "" {
+		return "index.html"
+	}
+	if path.Ext(clean) == "" {
+		return path.Join(clean, "index.html")
+	}
+	return clean
+}
+
+// Write renders every URL through h and writes the responses under out. Any
+// response other than 200 fails the export.
+func Write(ctx context.Context, h http.Handler, out string, urls []string) ([]Result, error) {
+	results := make([]Result, 0, len(urls))
+	for _, u := range urls {
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			return results, fmt.Errorf("failed to render %s: status %d", u, rec.Code)
+		}
+		if encoding := rec.Header().Get("Content-Encoding"); encoding != "" {
+			return results, fmt.Errorf("failed to render %s: unexpected %s encoding", u, encoding)
+		}
+
+		file := FilePath(u)
+		dest := filepath.Join(out, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return results, fmt.Errorf("failed to create directory for %s: %w", file, err)
+		}
+		if err := os.WriteFile(dest, rec.Body.Bytes(), 0o644); err != nil {
+			return results, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+
+		results = append(results, Result{URL: u, File: file, Size: rec.Body.Len()})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// BrokenLink is an internal link that no exported file serves
+type BrokenLink struct {
+	// File is the exported file containing the link
+	File string
+	Link string
+}
+
+func (b BrokenLink) String() string {
+	return b.File + ": " + b.Link
+}
+
+// linkAttrs are the HTML attributes holding links to check, including form
+// targets, which must reach the API rather than the static host
+var linkAttrs = map[string]bool{"href": true, "src": true, "action": true, "formaction": true, "hx-post": true}
+
+// cssURL matches url(...) references in stylesheets
+var cssURL = regexp.MustCompile(`url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+
+// Verify checks that every internal link in the HTML and CSS files under out
+// points to an exported file
+func Verify(out string) ([]BrokenLink, error) {
+	var broken []BrokenLink
+
+	err := filepath.WalkDir(out, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		var links []string
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".html":
+			links, err = htmlLinks(p)
+		case ".css":
+			links, err = cssLinks(p)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(out, p)
+		if err != nil {
+			return err
+		}
+		file := filepath.ToSlash(rel)
+
+		for _, link := range links {
+			target, internal := resolve(file, link)
+			if internal && !exists(out, target) {
+				broken = append(broken, BrokenLink{File: file, Link: link})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify links in %s: %w", out, err)
+	}
+
+	return broken, nil
+}
+
+func htmlLinks(p string) ([]string, error) {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			for {
+				key, value, more := tokenizer.TagAttr()
+				if linkAttrs[string(key)] {
+					links = append(links, string(value))
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+}
+
+func cssLinks(p string) ([]string, error) {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+	for _, match := range cssURL.FindAllSubmatch(content, -1) {
+		links = append(links, string(match[1]))
+	}
+	return links, nil
+}
+
+// resolve turns a link found in file into a URL path; links to other hosts,
+// fragments and non-HTTP schemes are not internal
+func resolve(file, link string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+
+	if strings.HasPrefix(u.Path, "/") {
+		return u.Path, true
+	}
+
+	base := "/" + path.Dir(file) + "/"
+	return path.Join(base, u.Path), true
+}
+
+// exists reports whether a static host would find a file for the URL path
+func exists(out, urlPath string) bool {
+	candidates := []string{strings.TrimPrefix(path.Clean(urlPath), "/"), FilePath(urlPath)}
+	for _, c := range candidates {
+		if info, err := os.Stat(filepath.Join(out, filepath.FromSlash(c))); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
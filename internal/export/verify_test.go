@@ -0,0 +1,60 @@
+package export_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/export"
+)
+
+func TestVerifyReportsBrokenLinksAndFormTargets(t *testing.T) {
+	out := t.TempDir()
+	files := map[string]string{
+		"index.html": `<html><head><link rel="stylesheet" href="/static/css/style.css"></head><body>
+			<a href="/about">About</a>
+			<a href="/missing">Missing</a>
+			<a href="https://example.com/elsewhere">External</a>
+			<a href="#top">Top</a>
+			<form method="post" action="/consent"><button formaction="/api/erase">Erase</button></form>
+			<form hx-post="https://api.example.com/api/contact"></form>
+			<form hx-post="/api/contact"></form>
+			<script src="/static/js/analytics.js" data-api="https://api.example.com"></script>
+		</body></html>`,
+		"about/index.html":       `<a href="../">Home</a><img src="poster.jpg">`,
+		"static/css/style.css":   `body { background: url("/static/img/bg.png"); }`,
+		"static/js/analytics.js": `navigator.sendBeacon("/api/view")`,
+	}
+	for name, content := range files {
+		path := filepath.Join(out, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	broken, err := export.Verify(out)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	var got []string
+	for _, b := range broken {
+		got = append(got, b.String())
+	}
+	slices.Sort(got)
+	want := []string{
+		"about/index.html: poster.jpg",
+		"index.html: /api/contact",
+		"index.html: /api/erase",
+		"index.html: /consent",
+		"index.html: /missing",
+		"static/css/style.css: /static/img/bg.png",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("broken links:\n%v\nwant:\n%v", got, want)
+	}
+}
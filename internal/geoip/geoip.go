@@ -0,0 +1,49 @@
+// Package geoip resolves client addresses to countries with a local MaxMind
+// (GeoLite2 or DB-IP Lite) country database.
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DB looks up countries in a database file
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// Open loads the country database at path
+func Open(path string) (*DB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+
+	return &DB{reader: reader}, nil
+}
+
+// Country returns the ISO 3166-1 code of the country of ip, or "" if unknown
+func (d *DB) Country(ip net.IP) string {
+	if d == nil || ip == nil {
+		return ""
+	}
+
+	var r record
+	if err := d.reader.Lookup(ip, &r); err != nil {
+		return ""
+	}
+	return r.Country.ISOCode
+}
+
+// Close releases the database
+func (d *DB) Close() error {
+	return d.reader.Close()
+}
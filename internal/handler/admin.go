@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/privacy"
+)
+
+// privacyRequest names the data subject of an export or erasure. It is
+// posted in the body, so the address stays out of URLs and access logs.
+type privacyRequest struct {
+	Email string `json:"email"`
+}
+
+// bindPrivacyRequest reads the subject's email from a JSON body. Forms are
+// refused: admin routes skip CSRF checks, and cross-site pages cannot post
+// JSON without a CORS preflight.
+func (h *Handler) bindPrivacyRequest(c echo.Context) (string, error) {
+	if !strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return "", echo.NewHTTPError(http.StatusUnsupportedMediaType, "JSON body required")
+	}
+	var req privacyRequest
+	if err := (&echo.DefaultBinder{}).BindBody(c, &req); err != nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	email := strings.TrimSpace(req.Email)
+	if email == "" || h.Submissions == nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "email is required")
+	}
+	return email, nil
+}
+
+// AdminPrivacyExportHandlerEcho returns all personal data tied to an email address as JSON.
+func (h *Handler) AdminPrivacyExportHandlerEcho(c echo.Context) error {
+	email, err := h.bindPrivacyRequest(c)
+	if err != nil {
+		return err
+	}
+
+	export := privacy.ExportByEmail(h.Submissions, email)
+	slog.InfoContext(c.Request().Context(), "Personal data exported", "email", privacy.RedactEmail(email), "submissions", len(export.Submissions))
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="personal-data.json"`)
+	return c.JSONPretty(http.StatusOK, export, "  ")
+}
+
+// AdminPrivacyEraseHandlerEcho deletes all personal data tied to an email address.
+func (h *Handler) AdminPrivacyEraseHandlerEcho(c echo.Context) error {
+	email, err := h.bindPrivacyRequest(c)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := privacy.EraseByEmail(h.Submissions, email)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "Failed to erase personal data", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return c.JSON(http.StatusOK, map[string]int{
+		"deleted": deleted,
+	})
+}
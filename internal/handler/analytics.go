@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/analytics"
+	"github.com/lexfrei/ne-stat-toboy/web/template"
+)
+
+// maxBeaconSize limits the body of a page view beacon
+const maxBeaconSize = 2 << 10
+
+// dashboardDays is the default period of the analytics dashboard
+const dashboardDays = 30
+
+// beacon is what the page script reports for a view
+type beacon struct {
+	URL      string `json:"url"`
+	Referrer string `json:"referrer"`
+}
+
+// ViewBeaconHandlerEcho counts a page view reported by the page script.
+// Views of unknown pages and by crawlers are ignored.
+func (h *Handler) ViewBeaconHandlerEcho(c echo.Context) error {
+	if h.PageViews == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxBeaconSize))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad Request")
+	}
+
+	// Beacons are sent as text/plain to avoid a preflight, so the body is
+	// decoded regardless of the content type
+	var b beacon
+	if err := json.Unmarshal(body, &b); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad Request")
+	}
+
+	view, err := analytics.NewView(b.URL, b.Referrer, c.Request().UserAgent())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad Request")
+	}
+	if view.Device == analytics.DeviceBot || !slices.Contains(PublicPages, view.Path) {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	// The address is only used for the country lookup and never stored
+	view.Country = h.GeoIP.Country(net.ParseIP(c.RealIP()))
+
+	h.PageViews.Record(view, time.Now())
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AdminAnalyticsHandlerEcho shows the page view statistics of the last days.
+func (h *Handler) AdminAnalyticsHandlerEcho(c echo.Context) error {
+	days := dashboardDays
+	if n, err := strconv.Atoi(c.QueryParam("days")); err == nil && n > 0 && n <= 365 {
+		days = n
+	}
+
+	data := template.AnalyticsData{Days: days}
+	if h.PageViews != nil {
+		now := time.Now()
+		data.Daily = h.PageViews.Daily(days, now)
+		data.Summary = h.PageViews.Summary(days, now)
+	}
+
+	if err := h.render(c, "analytics", template.Analytics(data)); err != nil {
+		return handleTemplateError(err, c, "Failed to render analytics page")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/analytics"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+)
+
+const browserUA = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36"
+
+func TestViewBeacon(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		userAgent  string
+		wantStatus int
+		wantViews  int64
+	}{
+		{"public page", `{"url":"https://example.com/about?utm_source=tg","referrer":"https://t.me/channel"}`, browserUA, http.StatusNoContent, 1},
+		{"crawler", `{"url":"https://example.com/about"}`, "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", http.StatusNoContent, 0},
+		{"no user agent", `{"url":"https://example.com/about"}`, "", http.StatusNoContent, 0},
+		{"page that is not public", `{"url":"https://example.com/admin/analytics"}`, browserUA, http.StatusNoContent, 0},
+		{"unknown page", `{"url":"https://example.com/wp-login.php"}`, browserUA, http.StatusNoContent, 0},
+		{"relative URL", `{"url":"/about"}`, browserUA, http.StatusBadRequest, 0},
+		{"not JSON", `url=https://example.com/`, browserUA, http.StatusBadRequest, 0},
+		{"oversized body", `{"url":"https://example.com/","referrer":"https://example.org/` + strings.Repeat("a", 4<<10) + `"}`, browserUA, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := analytics.NewCounter()
+			h := handler.New(handler.WithAnalytics(counter, nil))
+			e := echo.New()
+			e.POST("/api/view", h.ViewBeaconHandlerEcho)
+
+			// Beacons are sent as text/plain
+			req := httptest.NewRequest(http.MethodPost, "/api/view", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "text/plain;charset=UTF-8")
+			req.Header.Set("User-Agent", tt.userAgent)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if views := counter.Summary(1, time.Now()).Views; views != tt.wantViews {
+				t.Errorf("counted %d views, want %d", views, tt.wantViews)
+			}
+		})
+	}
+}
+
+func TestViewBeaconCapsDistinctValues(t *testing.T) {
+	counter := analytics.NewCounter()
+	h := handler.New(handler.WithAnalytics(counter, nil))
+	e := echo.New()
+	e.POST("/api/view", h.ViewBeaconHandlerEcho)
+
+	const beacons = 500
+	for i := range beacons {
+		body := fmt.Sprintf(`{"url":"https://example.com/?utm_source=s%d","referrer":"https://r%d.example/"}`, i, i)
+		req := httptest.NewRequest(http.MethodPost, "/api/view", strings.NewReader(body))
+		req.Header.Set("User-Agent", browserUA)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d", rec.Code)
+		}
+	}
+
+	day := counter.Summary(1, time.Now())
+	if day.Views != beacons {
+		t.Errorf("counted %d views, want %d", day.Views, beacons)
+	}
+	for name, counts := range map[string]map[string]int64{"referrers": day.Referrers, "campaigns": day.Campaigns} {
+		// 200 values plus Other
+		if len(counts) > 201 {
+			t.Errorf("%d distinct %s kept from unauthenticated beacons", len(counts), name)
+		}
+		if counts[analytics.Other] == 0 {
+			t.Errorf("%s beyond the cap not counted under %q", name, analytics.Other)
+		}
+	}
+}
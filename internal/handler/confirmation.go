@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/mailer"
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+	"github.com/lexfrei/ne-stat-toboy/web/template"
+)
+
+// errConfirmationDisabled is returned when no signer or storage is configured
+var errConfirmationDisabled = errors.New("confirmation links disabled")
+
+// sendConfirmation emails the submitter a copy of their message with a signed verification link
+func (h *Handler) sendConfirmation(ctx context.Context, sub storage.Submission) {
+	if h.Mailer == nil || h.Signer == nil {
+		slog.InfoContext(ctx, "Confirmation email skipped - mailer not configured")
+		return
+	}
+
+	if h.MailLimiter != nil && !h.MailLimiter.Allow(sub.Email) {
+		slog.InfoContext(ctx, "Confirmation email skipped - rate limited", "submission", sub.ID)
+		return
+	}
+
+	token := h.Signer.Sign(sub.ID, time.Now().Add(h.ConfirmationTTL))
+	link := strings.TrimRight(h.BaseURL, "/") + "/contact/confirm?token=" + url.QueryEscape(token)
+
+	// The email outlives the request but keeps its request and trace IDs
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+
+		msg, err := mailer.Confirmation{
+			SubmissionID: sub.ID,
+			Locale:       sub.Locale,
+			Name:         sub.Name,
+			Message:      sub.Message,
+			ConfirmURL:   link,
+		}.Render(ctx, sub.Email)
+		if err != nil {
+			notificationFailuresTotal.WithLabelValues(channelEmail).Inc()
+			slog.ErrorContext(ctx, "Failed to render confirmation email", "submission", sub.ID, "error", err)
+			return
+		}
+
+		start := time.Now()
+		err = h.Mailer.Send(ctx, msg)
+		notificationDuration.WithLabelValues(channelEmail).Observe(time.Since(start).Seconds())
+		if err != nil {
+			notificationFailuresTotal.WithLabelValues(channelEmail).Inc()
+			slog.ErrorContext(ctx, "Failed to send confirmation email", "submission", sub.ID, "error", err)
+			return
+		}
+
+		slog.InfoContext(ctx, "Confirmation email sent", "submission", sub.ID)
+	}()
+}
+
+// ContactConfirmHandlerEcho shows the landing page of the link from the
+// confirmation email. Opening the link does not confirm anything, since mail
+// scanners open links too.
+func (h *Handler) ContactConfirmHandlerEcho(c echo.Context) error {
+	token := c.QueryParam("token")
+	if _, err := h.verifyConfirmation(c, token); err != nil {
+		return h.renderConfirmed(c, false)
+	}
+
+	csrfToken, _ := c.Get("csrf").(string)
+	if err := h.render(c, "confirmation", template.ContactConfirm(token, csrfToken)); err != nil {
+		return handleTemplateError(err, c, "Failed to render confirmation page")
+	}
+	return nil
+}
+
+// ContactConfirmSubmitHandlerEcho confirms the submission of the token posted
+// from the landing page.
+func (h *Handler) ContactConfirmSubmitHandlerEcho(c echo.Context) error {
+	id, err := h.verifyConfirmation(c, c.FormValue("token"))
+	if err != nil {
+		return h.renderConfirmed(c, false)
+	}
+
+	if _, err := h.Submissions.Confirm(id, time.Now()); err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			slog.ErrorContext(c.Request().Context(), "Failed to confirm submission", "submission", id, "error", err)
+		}
+		return h.renderConfirmed(c, false)
+	}
+
+	slog.InfoContext(c.Request().Context(), "Submission confirmed", "submission", id)
+	return h.renderConfirmed(c, true)
+}
+
+// verifyConfirmation returns the submission ID signed into a confirmation token
+func (h *Handler) verifyConfirmation(c echo.Context, token string) (string, error) {
+	if h.Signer == nil || h.Submissions == nil {
+		return "", errConfirmationDisabled
+	}
+
+	id, err := h.Signer.Verify(token, time.Now())
+	if err != nil {
+		slog.InfoContext(c.Request().Context(), "Invalid confirmation token", "error", err)
+		return "", err
+	}
+	return id, nil
+}
+
+func (h *Handler) renderConfirmed(c echo.Context, confirmed bool) error {
+	if err := h.render(c, "confirmation", template.ContactConfirmed(confirmed)); err != nil {
+		return handleTemplateError(err, c, "Failed to render confirmation page")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+	"github.com/lexfrei/ne-stat-toboy/internal/signing"
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+)
+
+func TestContactConfirmation(t *testing.T) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "submissions.json"))
+	if err != nil {
+		t.Fatalf("storage.Open: %v", err)
+	}
+	sub, err := store.Create(storage.Submission{Email: "visitor@example.com"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	signer := signing.New([]byte("secret"))
+	h := handler.New(
+		handler.WithSubmissionStore(store),
+		handler.WithConfirmationLinks(signer, "https://example.com", time.Hour),
+	)
+	e := echo.New()
+	e.GET("/contact/confirm", h.ContactConfirmHandlerEcho)
+	e.POST("/contact/confirm", h.ContactConfirmSubmitHandlerEcho)
+
+	// Tokens are signed with the key derived for confirmation links
+	token := signer.For("contact-confirmation").Sign(sub.ID, time.Now().Add(time.Hour))
+
+	get := func(token string) string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/contact/confirm?token="+url.QueryEscape(token), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET status = %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+	post := func(token string) string {
+		req := httptest.NewRequest(http.MethodPost, "/contact/confirm", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST status = %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+	confirmed := func() bool {
+		got, err := store.Get(sub.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		return got.Confirmed()
+	}
+
+	// Opening the link, as mail scanners do, only shows the form
+	if body := get(token); !strings.Contains(body, `name="token"`) || !strings.Contains(body, `method="post"`) {
+		t.Errorf("landing page without a confirmation form:\n%s", body)
+	}
+	if confirmed() {
+		t.Fatal("submission confirmed by opening the link")
+	}
+
+	expired := signer.For("contact-confirmation").Sign(sub.ID, time.Now().Add(-time.Minute))
+	consentCookie := consent.NewManager(signer, "v1", time.Hour).Cookie(consent.NewChoice(), time.Now()).Value
+	for name, bad := range map[string]string{
+		"expired":        expired,
+		"consent cookie": consentCookie,
+		"parent key":     signer.Sign(sub.ID, time.Now().Add(time.Hour)),
+		"garbage":        "not-a-token",
+	} {
+		if body := get(bad); !strings.Contains(body, "Ссылка недействительна") || strings.Contains(body, `name="token"`) {
+			t.Errorf("%s: landing page offers to confirm an invalid token", name)
+		}
+		if body := post(bad); !strings.Contains(body, "Ссылка недействительна") {
+			t.Errorf("%s: invalid token not reported", name)
+		}
+	}
+	if confirmed() {
+		t.Fatal("submission confirmed with an invalid token")
+	}
+
+	if body := post(token); !strings.Contains(body, "Адрес подтвержден") {
+		t.Errorf("confirmation page:\n%s", body)
+	}
+	if !confirmed() {
+		t.Error("submission not confirmed by the form")
+	}
+}
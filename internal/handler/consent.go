@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+)
+
+// ConsentHandlerEcho stores the visitor's choice from the consent banner or
+// the privacy page and sends them back to the page they came from.
+func (h *Handler) ConsentHandlerEcho(c echo.Context) error {
+	if h.Consent == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Not Found")
+	}
+
+	// The banner is part of cached pages and carries no CSRF token, so the
+	// request must come from our own pages
+	request := c.Request()
+	if site := request.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" {
+		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+	}
+	if origin := request.Header.Get(echo.HeaderOrigin); origin != "" && !sameHost(origin, request.Host) {
+		return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+	}
+
+	var choice consent.Choice
+	switch c.FormValue("choice") {
+	case "all":
+		choice = consent.NewChoice(consent.Categories...)
+	case "necessary":
+		choice = consent.NewChoice()
+	case "custom":
+		form, err := c.FormParams()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad Request")
+		}
+		choice = consent.NewChoice(form["category"]...)
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad Request")
+	}
+
+	c.SetCookie(h.Consent.Cookie(choice, time.Now()))
+	consent.Record(choice)
+
+	return c.Redirect(http.StatusSeeOther, returnPath(request.Referer(), request.Host))
+}
+
+// returnPath is the local path of referer, or the home page if the referer
+// is missing or belongs to another site
+func returnPath(referer, host string) string {
+	u, err := url.Parse(referer)
+	if err != nil || !strings.EqualFold(u.Host, host) || !strings.HasPrefix(u.Path, "/") {
+		return "/"
+	}
+	return u.Path
+}
+
+func sameHost(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, host)
+}
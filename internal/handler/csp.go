@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/csp"
+	"github.com/lexfrei/ne-stat-toboy/web/template"
+)
+
+// maxCSPReportSize limits the body of a violation report request
+const maxCSPReportSize = 64 << 10
+
+// CSPReportHandlerEcho receives CSP violation reports sent by browsers.
+func (h *Handler) CSPReportHandlerEcho(c echo.Context) error {
+	if h.CSPReports == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxCSPReportSize))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad Request")
+	}
+
+	violations, err := csp.ParseReports(c.Request().Header.Get(echo.HeaderContentType), body)
+	if errors.Is(err, csp.ErrUnsupportedReport) {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Unsupported Media Type")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad Request")
+	}
+
+	now := time.Now().UTC()
+	for _, v := range violations {
+		h.CSPReports.Record(v, now)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AdminCSPReportsHandlerEcho lists the collected CSP violations.
+func (h *Handler) AdminCSPReportsHandlerEcho(c echo.Context) error {
+	var violations []csp.Violation
+	if h.CSPReports != nil {
+		violations = h.CSPReports.List()
+	}
+
+	if err := h.render(c, "csp_reports", template.CSPReports(violations)); err != nil {
+		return handleTemplateError(err, c, "Failed to render CSP reports page")
+	}
+	return nil
+}
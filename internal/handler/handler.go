@@ -2,8 +2,7 @@
 package handler
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"html"
 	"log/slog"
@@ -11,42 +10,175 @@ import (
 	"strings"
 	"time"
 
+	"github.com/a-h/templ"
 	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/analytics"
+	"github.com/lexfrei/ne-stat-toboy/internal/bot"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+	"github.com/lexfrei/ne-stat-toboy/internal/csp"
+	"github.com/lexfrei/ne-stat-toboy/internal/geoip"
+	"github.com/lexfrei/ne-stat-toboy/internal/health"
+	"github.com/lexfrei/ne-stat-toboy/internal/locale"
+	"github.com/lexfrei/ne-stat-toboy/internal/mailer"
 	"github.com/lexfrei/ne-stat-toboy/internal/model"
+	"github.com/lexfrei/ne-stat-toboy/internal/privacy"
+	"github.com/lexfrei/ne-stat-toboy/internal/signing"
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+	"github.com/lexfrei/ne-stat-toboy/internal/tracing"
+	"github.com/lexfrei/ne-stat-toboy/internal/upload"
 	"github.com/lexfrei/ne-stat-toboy/web/template"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// TelegramMessage represents the structure for sending messages to Telegram API
-type TelegramMessage struct {
-	ChatID    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode,omitempty"`
-}
-
 // Handler contains all HTTP handlers and their dependencies.
 type Handler struct {
 	FilmInfo       model.FilmInfo
-	TelegramToken  string
+	Telegram       *telegram.Client
 	TelegramChatID string
+	Uploads        *upload.Store
+	Submissions    *storage.Store
+
+	// Telegram update receiver for replies from the team chat
+	Bot           *bot.Bot
+	WebhookSecret string
+
+	// Confirmation emails for contact form submitters
+	Mailer          mailer.Mailer
+	MailLimiter     *mailer.RateLimiter
+	Signer          *signing.Signer
+	BaseURL         string
+	ConfirmationTTL time.Duration
+
+	// Personal data processing policy
+	PolicyVersion string
+	Retention     time.Duration
+
+	// Collector of Content-Security-Policy violation reports
+	CSPReports *csp.Collector
+
+	// Absolute URL of the API that forms post to, for pages served elsewhere
+	APIURL string
+
+	// First-party page view statistics, with countries from an optional GeoIP database
+	PageViews *analytics.Counter
+	GeoIP     *geoip.DB
+
+	// Visitors' choices on optional cookies and third-party scripts
+	Consent *consent.Manager
+
+	// Readiness checks of the dependencies
+	Health *health.Checker
 }
 
 // HandlerOption is a functional option for configuring the handler
 type HandlerOption func(*Handler)
 
 // WithTelegramConfig configures Telegram notification settings
-func WithTelegramConfig(token, chatID string) HandlerOption {
+func WithTelegramConfig(client *telegram.Client, chatID string) HandlerOption {
 	return func(h *Handler) {
-		h.TelegramToken = token
+		h.Telegram = client
 		h.TelegramChatID = chatID
 	}
 }
 
+// WithTelegramWebhook routes webhook updates verified by secret to the bot
+func WithTelegramWebhook(b *bot.Bot, secret string) HandlerOption {
+	return func(h *Handler) {
+		h.Bot = b
+		h.WebhookSecret = secret
+	}
+}
+
+// WithUploadStore enables file uploads through the given store
+func WithUploadStore(store *upload.Store) HandlerOption {
+	return func(h *Handler) {
+		h.Uploads = store
+	}
+}
+
+// WithSubmissionStore persists contact form submissions in the given store
+func WithSubmissionStore(store *storage.Store) HandlerOption {
+	return func(h *Handler) {
+		h.Submissions = store
+	}
+}
+
+// WithMailer enables confirmation emails, rate-limited per recipient address
+func WithMailer(m mailer.Mailer, limiter *mailer.RateLimiter) HandlerOption {
+	return func(h *Handler) {
+		h.Mailer = m
+		h.MailLimiter = limiter
+	}
+}
+
+// WithConfirmationLinks configures the verification links sent to submitters,
+// signed with a key derived from signer
+func WithConfirmationLinks(signer *signing.Signer, baseURL string, ttl time.Duration) HandlerOption {
+	return func(h *Handler) {
+		if signer != nil {
+			h.Signer = signer.For("contact-confirmation")
+		}
+		h.BaseURL = baseURL
+		h.ConfirmationTTL = ttl
+	}
+}
+
+// WithCSPReports stores CSP violation reports in the given collector
+func WithCSPReports(collector *csp.Collector) HandlerOption {
+	return func(h *Handler) {
+		h.CSPReports = collector
+	}
+}
+
+// WithPrivacyPolicy sets the consent policy version and the retention period of submissions
+func WithPrivacyPolicy(version string, retention time.Duration) HandlerOption {
+	return func(h *Handler) {
+		h.PolicyVersion = version
+		h.Retention = retention
+	}
+}
+
+// WithAnalytics counts page views reported by the page script; geo may be nil
+func WithAnalytics(counter *analytics.Counter, geo *geoip.DB) HandlerOption {
+	return func(h *Handler) {
+		h.PageViews = counter
+		h.GeoIP = geo
+	}
+}
+
+// WithConsent stores visitors' consent choices with the given manager
+func WithConsent(manager *consent.Manager) HandlerOption {
+	return func(h *Handler) {
+		h.Consent = manager
+	}
+}
+
+// WithHealth reports the outcome of the checker's checks on readiness probes
+func WithHealth(checker *health.Checker) HandlerOption {
+	return func(h *Handler) {
+		h.Health = checker
+	}
+}
+
+// WithAPIURL makes forms post to the API at url instead of the serving origin,
+// as needed by the static export. Such pages carry no CSRF token: the API
+// checks cross-origin posts by their Origin header instead.
+func WithAPIURL(url string) HandlerOption {
+	return func(h *Handler) {
+		h.APIURL = strings.TrimRight(url, "/")
+	}
+}
+
 // New creates a new Handler with initialized dependencies.
 func New(opts ...HandlerOption) *Handler {
 	h := &Handler{
 		// Default empty values for Telegram config
-		TelegramToken:  "",
-		TelegramChatID: "",
+		Telegram:        nil,
+		TelegramChatID:  "",
+		ConfirmationTTL: 48 * time.Hour,
 		FilmInfo: model.FilmInfo{
 			Title:           "Не стать тобой",
 			Tagline:         "Жизнь в семье, где любовь выражается насилием, ложью и запретами, подталкивает школьницу к тяжелому выбору - смерть или предательство ради жизни",
@@ -104,7 +236,7 @@ func New(opts ...HandlerOption) *Handler {
 // HomeHandlerEcho renders the home page.
 func (h *Handler) HomeHandlerEcho(c echo.Context) error {
 	component := template.Home(h.FilmInfo)
-	if err := component.Render(c.Request().Context(), c.Response().Writer); err != nil {
+	if err := h.render(c, "home", component); err != nil {
 		return handleTemplateError(err, c, "Failed to render home page")
 	}
 	return nil
@@ -113,7 +245,7 @@ func (h *Handler) HomeHandlerEcho(c echo.Context) error {
 // AboutHandlerEcho renders the about page.
 func (h *Handler) AboutHandlerEcho(c echo.Context) error {
 	component := template.About(h.FilmInfo)
-	if err := component.Render(c.Request().Context(), c.Response().Writer); err != nil {
+	if err := h.render(c, "about", component); err != nil {
 		return handleTemplateError(err, c, "Failed to render about page")
 	}
 	return nil
@@ -122,7 +254,7 @@ func (h *Handler) AboutHandlerEcho(c echo.Context) error {
 // TeamHandlerEcho renders the team page.
 func (h *Handler) TeamHandlerEcho(c echo.Context) error {
 	component := template.Team(h.FilmInfo)
-	if err := component.Render(c.Request().Context(), c.Response().Writer); err != nil {
+	if err := h.render(c, "team", component); err != nil {
 		return handleTemplateError(err, c, "Failed to render team page")
 	}
 	return nil
@@ -131,7 +263,7 @@ func (h *Handler) TeamHandlerEcho(c echo.Context) error {
 // LocationsHandlerEcho renders the locations page.
 func (h *Handler) LocationsHandlerEcho(c echo.Context) error {
 	component := template.Locations(h.FilmInfo)
-	if err := component.Render(c.Request().Context(), c.Response().Writer); err != nil {
+	if err := h.render(c, "locations", component); err != nil {
 		return handleTemplateError(err, c, "Failed to render locations page")
 	}
 	return nil
@@ -139,26 +271,37 @@ func (h *Handler) LocationsHandlerEcho(c echo.Context) error {
 
 // ContactHandlerEcho renders the contact page.
 func (h *Handler) ContactHandlerEcho(c echo.Context) error {
-	// We need to properly pass the CSRF token to the template
-	csrfToken := c.Get("csrf").(string)
+	data := template.ContactData{
+		Film:   h.FilmInfo,
+		APIURL: h.APIURL,
+	}
 
-	// Create a custom data structure with film info and CSRF token
-	data := struct {
-		Film      model.FilmInfo
-		CSRFToken string
-	}{
-		Film:      h.FilmInfo,
-		CSRFToken: csrfToken,
+	// Pages posting to another origin are not protected by the CSRF cookie
+	if h.APIURL == "" {
+		data.CSRFToken, _ = c.Get("csrf").(string)
 	}
 
 	// Pass the data to the template
 	component := template.ContactWithCSRF(data)
-	if err := component.Render(c.Request().Context(), c.Response().Writer); err != nil {
+	if err := h.render(c, "contact", component); err != nil {
 		return handleTemplateError(err, c, "Failed to render contact page")
 	}
 	return nil
 }
 
+// PrivacyHandlerEcho renders the privacy policy page.
+func (h *Handler) PrivacyHandlerEcho(c echo.Context) error {
+	component := template.Privacy(template.PrivacyData{
+		PolicyVersion: h.PolicyVersion,
+		RetentionDays: int(h.Retention.Hours() / 24),
+		ContactEmail:  h.FilmInfo.ContactEmail,
+	})
+	if err := h.render(c, "privacy", component); err != nil {
+		return handleTemplateError(err, c, "Failed to render privacy page")
+	}
+	return nil
+}
+
 // ContactSubmitHandlerEcho processes contact form submissions.
 func (h *Handler) ContactSubmitHandlerEcho(c echo.Context) error {
 	// Validate CSRF token (handled by middleware)
@@ -167,7 +310,9 @@ func (h *Handler) ContactSubmitHandlerEcho(c echo.Context) error {
 	name := strings.TrimSpace(c.FormValue("name"))
 	email := strings.TrimSpace(c.FormValue("email"))
 	message := strings.TrimSpace(c.FormValue("message"))
-	timeStamp := time.Now().Format(time.RFC3339)
+	consent := c.FormValue("consent") != ""
+	now := time.Now()
+	timeStamp := now.Format(time.RFC3339)
 
 	// Validate input
 	errors := make(map[string]string)
@@ -192,7 +337,13 @@ func (h *Handler) ContactSubmitHandlerEcho(c echo.Context) error {
 		errors["message"] = "Сообщение слишком длинное (максимум 5000 символов)"
 	}
 
+	if !consent {
+		errors["consent"] = "Необходимо согласие на обработку персональных данных"
+	}
+
 	if len(errors) > 0 {
+		contactSubmissionsTotal.WithLabelValues(outcomeInvalid).Inc()
+
 		// For HTMX requests, return form with errors
 		c.Response().Header().Set("HX-Trigger", "{\"showFormErrors\": true}")
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{
@@ -205,10 +356,32 @@ func (h *Handler) ContactSubmitHandlerEcho(c echo.Context) error {
 	email = sanitizeString(email)
 	message = sanitizeString(message)
 
+	// Store the submission so it can be confirmed later
+	submission := storage.Submission{
+		Name:           name,
+		Email:          email,
+		Message:        message,
+		Locale:         locale.FromRequest(c.Request()),
+		ConsentVersion: h.PolicyVersion,
+		ConsentAt:      now.UTC(),
+	}
+	outcome := outcomeAccepted
+	if h.Submissions != nil {
+		stored, err := h.Submissions.Create(submission)
+		if err != nil {
+			slog.ErrorContext(c.Request().Context(), "Failed to store contact submission", "error", err)
+			outcome = outcomeStoreFailed
+		} else {
+			submission = stored
+		}
+	}
+	contactSubmissionsTotal.WithLabelValues(outcome).Inc()
+
 	// Log the submission
-	slog.Info("Contact form submission",
+	slog.InfoContext(c.Request().Context(), "Contact form submission",
+		"id", submission.ID,
 		"name", name,
-		"email", email,
+		"email", privacy.RedactEmail(email),
 		"message_length", len(message),
 		"time", timeStamp)
 
@@ -222,61 +395,94 @@ func (h *Handler) ContactSubmitHandlerEcho(c echo.Context) error {
 		html.EscapeString(name), html.EscapeString(email), timeStamp, html.EscapeString(message))
 
 	// Send to Telegram
-	err := h.sendTelegramMessage(telegramMsg)
+	var keyboard *telegram.InlineKeyboardMarkup
+	if submission.ID != "" {
+		telegramMsg += "\n\n<b>ID:</b> <code>" + submission.ID + "</code>"
+		keyboard = bot.SubmissionKeyboard(submission.ID)
+	}
+	messageID, err := h.sendTelegramMessage(c.Request().Context(), telegramMsg, keyboard)
 	if err != nil {
-		slog.Error("Failed to send message to Telegram", "error", err)
+		slog.ErrorContext(c.Request().Context(), "Failed to send message to Telegram", "submission", submission.ID, "error", err)
 		// Continue anyway - don't show error to user
 	}
 
+	// Remember the notification so that replies in the chat reach the submitter
+	if messageID != 0 && submission.ID != "" {
+		_, err := h.Submissions.Update(submission.ID, func(s *storage.Submission) error {
+			s.TelegramMessageIDs = append(s.TelegramMessageIDs, messageID)
+			return nil
+		})
+		if err != nil {
+			slog.ErrorContext(c.Request().Context(), "Failed to link Telegram notification to submission", "error", err)
+		}
+	}
+
+	// Auto-reply with a copy of the message and a verification link
+	if submission.ID != "" {
+		h.sendConfirmation(c.Request().Context(), submission)
+	}
+
 	// Return success template
 	component := template.ContactSuccess()
-	if err := component.Render(c.Request().Context(), c.Response().Writer); err != nil {
+	if err := h.render(c, "contact_success", component); err != nil {
 		return handleTemplateError(err, c, "Failed to render contact success page")
 	}
 	return nil
 }
 
-// sendTelegramMessage sends a message to the specified Telegram chat
-func (h *Handler) sendTelegramMessage(text string) error {
+// sendTelegramMessage sends a message to the configured Telegram chat and
+// returns its message ID; failures are left to the caller to log
+func (h *Handler) sendTelegramMessage(ctx context.Context, text string, keyboard *telegram.InlineKeyboardMarkup) (int64, error) {
 	// Check if Telegram is configured
-	if h.TelegramToken == "" || h.TelegramChatID == "" {
-		slog.Info("Telegram notification skipped - token or chat ID not configured")
-		return nil
+	if h.Telegram == nil || h.TelegramChatID == "" {
+		slog.InfoContext(ctx, "Telegram notification skipped - token or chat ID not configured")
+		return 0, nil
 	}
 
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", h.TelegramToken)
-
-	msg := TelegramMessage{
-		ChatID:    h.TelegramChatID,
-		Text:      text,
-		ParseMode: "HTML", // Allow HTML formatting
-	}
-
-	payload, err := json.Marshal(msg)
+	ctx, span := tracing.Tracer().Start(ctx, "telegram.sendMessage",
+		trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	sent, err := h.Telegram.SendMessage(ctx, telegram.SendMessageRequest{
+		ChatID:      h.TelegramChatID,
+		Text:        text,
+		ParseMode:   "HTML", // Allow HTML formatting
+		ReplyMarkup: keyboard,
+	})
+	notificationDuration.WithLabelValues(channelTelegram).Observe(time.Since(start).Seconds())
 	if err != nil {
-		slog.Error("Failed to marshal Telegram message", "error", err)
-		return err
+		notificationFailuresTotal.WithLabelValues(channelTelegram).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "send failed")
+		return 0, err
 	}
+	span.SetAttributes(attribute.Int64("telegram.message_id", sent.MessageID))
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(payload))
-	if err != nil {
-		slog.Error("Failed to send Telegram message", "error", err)
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		slog.Error("Telegram API error", "status", resp.Status)
-		return fmt.Errorf("telegram API error: %s", resp.Status)
-	}
-
-	return nil
+	return sent.MessageID, nil
 }
 
 // validateEmail and sanitizeString functions are in validation.go
 
+// render writes the component to the response, timing it per page
+func (h *Handler) render(c echo.Context, page string, component templ.Component) error {
+	ctx, span := tracing.Tracer().Start(c.Request().Context(), "render "+page,
+		trace.WithAttributes(attribute.String("template.page", page)))
+	defer span.End()
+	ctx = template.WithAPIURL(ctx, h.APIURL)
+
+	start := time.Now()
+	err := component.Render(ctx, c.Response().Writer)
+	templateRenderDuration.WithLabelValues(page).Observe(time.Since(start).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "render failed")
+	}
+	return err
+}
+
 // handleTemplateError logs the error and returns a proper HTTP error response.
-func handleTemplateError(err error, _ echo.Context, message string) error {
-	slog.Error(message, "error", err)
+func handleTemplateError(err error, c echo.Context, message string) error {
+	slog.ErrorContext(c.Request().Context(), message, "error", err)
 	return echo.NewHTTPError(500, "Internal Server Error")
 }
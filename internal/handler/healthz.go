@@ -2,13 +2,32 @@ package handler
 
 import (
 	"net/http"
+
 	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/health"
+	"github.com/lexfrei/ne-stat-toboy/internal/version"
 )
 
-// HealthCheckHandler handles health check requests
+// HealthCheckHandler answers liveness probes: the process is up and serving requests
 func (h *Handler) HealthCheckHandler(c echo.Context) error {
-	// Simple health check - just return OK
-	return c.JSON(http.StatusOK, map[string]string{
-		"status": "ok",
-	})
+	return c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+// ReadinessHandlerEcho answers readiness probes with the outcome of the
+// dependency checks, failing with 503 if any check fails
+func (h *Handler) ReadinessHandlerEcho(c echo.Context) error {
+	if h.Health == nil {
+		return c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+	}
+
+	report := h.Health.Ready(c.Request().Context())
+	if !report.OK() {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// VersionHandlerEcho returns the build information of the running binary
+func (h *Handler) VersionHandlerEcho(c echo.Context) error {
+	return c.JSON(http.StatusOK, version.Get())
 }
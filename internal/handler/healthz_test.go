@@ -0,0 +1,61 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+	"github.com/lexfrei/ne-stat-toboy/internal/health"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	var failing bool
+	checker := health.New(time.Second)
+	checker.Add("storage", func(context.Context) error {
+		if failing {
+			return errors.New("disk full")
+		}
+		return nil
+	})
+	h := handler.New(handler.WithHealth(checker))
+
+	e := echo.New()
+	e.GET("/readyz", h.ReadinessHandlerEcho)
+	e.GET("/healthz", h.HealthCheckHandler)
+
+	probe := func(path string) (int, health.Report) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var report health.Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("invalid report %q: %v", rec.Body.String(), err)
+		}
+		return rec.Code, report
+	}
+
+	if code, report := probe("/readyz"); code != http.StatusOK || !report.OK() {
+		t.Errorf("healthy readiness = %d %+v, want 200 ok", code, report)
+	}
+
+	failing = true
+	code, report := probe("/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != health.StatusFail || report.Checks["storage"] != "disk full" {
+		t.Errorf("failing readiness = %d %+v, want 503 with the storage error", code, report)
+	}
+
+	failing = false
+	checker.Shutdown()
+	if code, _ := probe("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readiness while shutting down = %d, want 503", code)
+	}
+	// Liveness does not depend on the checks
+	if code, report := probe("/healthz"); code != http.StatusOK || !report.OK() {
+		t.Errorf("liveness = %d %+v, want 200 ok", code, report)
+	}
+}
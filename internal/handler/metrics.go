@@ -0,0 +1,58 @@
+package handler
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	contactSubmissionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "nestattoboy",
+		Subsystem: "contact",
+		Name:      "submissions_total",
+		Help:      "Number of contact form submissions by outcome.",
+	}, []string{"outcome"})
+
+	notificationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "nestattoboy",
+		Subsystem: "notification",
+		Name:      "duration_seconds",
+		Help:      "Time spent delivering notifications by channel.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"channel"})
+
+	notificationFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "nestattoboy",
+		Subsystem: "notification",
+		Name:      "failures_total",
+		Help:      "Number of notifications that could not be delivered by channel.",
+	}, []string{"channel"})
+
+	templateRenderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "nestattoboy",
+		Subsystem: "template",
+		Name:      "render_duration_seconds",
+		Help:      "Time spent rendering page templates by page.",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1},
+	}, []string{"page"})
+)
+
+// Outcomes of contact form submissions
+const (
+	outcomeInvalid     = "invalid"
+	outcomeAccepted    = "accepted"
+	outcomeStoreFailed = "store_failed"
+)
+
+// Notification channels
+const (
+	channelTelegram = "telegram"
+	channelEmail    = "email"
+)
+
+// Collectors returns the Prometheus collectors of the handlers
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		contactSubmissionsTotal,
+		notificationDuration,
+		notificationFailuresTotal,
+		templateRenderDuration,
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PublicPages lists the routes of the public site, as put into the sitemap
+// and the static export
+var PublicPages = []string{"/", "/about", "/team", "/locations", "/contact", "/privacy"}
+
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// SitemapHandlerEcho lists the public pages for search engines.
+func (h *Handler) SitemapHandlerEcho(c echo.Context) error {
+	base := strings.TrimRight(h.BaseURL, "/")
+
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, page := range PublicPages {
+		set.URLs = append(set.URLs, sitemapURL{Loc: base + page})
+	}
+
+	body, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, append([]byte(xml.Header), body...))
+}
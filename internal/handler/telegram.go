@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+)
+
+// TelegramWebhookHandlerEcho receives Bot API updates when the bot runs in webhook mode.
+func (h *Handler) TelegramWebhookHandlerEcho(c echo.Context) error {
+	if h.Bot == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Not Found")
+	}
+
+	secret := c.Request().Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if h.WebhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(h.WebhookSecret)) != 1 {
+		slog.WarnContext(c.Request().Context(), "Rejected Telegram webhook call with invalid secret", "remote_ip", c.RealIP())
+		return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var update telegram.Update
+	if err := c.Bind(&update); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad Request")
+	}
+
+	h.Bot.HandleUpdate(c.Request().Context(), update)
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,103 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/bot"
+	"github.com/lexfrei/ne-stat-toboy/internal/handler"
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram/telegramtest"
+)
+
+const webhookSecret = "webhook-secret-value"
+
+func TestTelegramWebhookChecksSecret(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+
+	store, err := storage.Open(filepath.Join(t.TempDir(), "submissions.json"))
+	if err != nil {
+		t.Fatalf("storage.Open: %v", err)
+	}
+	client := telegram.NewClient("123:test-token", telegram.WithBaseURL(server.URL))
+	h := handler.New(handler.WithTelegramWebhook(bot.New(client, "100", store), webhookSecret))
+
+	e := echo.New()
+	e.POST("/telegram/webhook", h.TelegramWebhookHandlerEcho)
+
+	update, err := json.Marshal(telegram.Update{
+		UpdateID: 1,
+		Message: &telegram.Message{
+			MessageID: 10,
+			From:      &telegram.User{ID: 7, FirstName: "Masha"},
+			Chat:      telegram.Chat{ID: 100, Type: "group"},
+			Text:      "/help",
+		},
+	})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		secret     string
+		wantStatus int
+		wantSent   int
+	}{
+		{"missing secret", "", http.StatusUnauthorized, 0},
+		{"wrong secret", "webhook-secret-valuX", http.StatusUnauthorized, 0},
+		{"secret prefix", "webhook-secret", http.StatusUnauthorized, 0},
+		{"valid secret", webhookSecret, http.StatusOK, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(server.Sent())
+
+			req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(string(update)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.secret != "" {
+				req.Header.Set("X-Telegram-Bot-Api-Secret-Token", tt.secret)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if sent := len(server.Sent()) - before; sent != tt.wantSent {
+				t.Errorf("bot sent %d messages, want %d", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestTelegramWebhookWithoutSecretIsRefused(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+
+	store, err := storage.Open(filepath.Join(t.TempDir(), "submissions.json"))
+	if err != nil {
+		t.Fatalf("storage.Open: %v", err)
+	}
+	client := telegram.NewClient("123:test-token", telegram.WithBaseURL(server.URL))
+	h := handler.New(handler.WithTelegramWebhook(bot.New(client, "100", store), ""))
+
+	e := echo.New()
+	e.POST("/telegram/webhook", h.TelegramWebhookHandlerEcho)
+
+	req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(`{"update_id":1}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401 when no secret is configured", rec.Code)
+	}
+}
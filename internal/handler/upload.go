@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/scanner"
+	"github.com/lexfrei/ne-stat-toboy/internal/upload"
+)
+
+// UploadHandlerEcho accepts casting headshots and press attachments.
+// Files are scanned for malware before they are stored.
+func (h *Handler) UploadHandlerEcho(c echo.Context) error {
+	if h.Uploads == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Загрузка файлов временно недоступна",
+		})
+	}
+
+	category := c.FormValue("category")
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Файл не выбран",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "Failed to open uploaded file", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal Server Error")
+	}
+	defer file.Close()
+
+	name, err := h.Uploads.Save(c.Request().Context(), category, fileHeader.Filename, file)
+
+	var infected *scanner.InfectedError
+	switch {
+	case err == nil:
+		slog.InfoContext(c.Request().Context(), "File uploaded", "category", category, "file", name, "size", fileHeader.Size)
+		return c.JSON(http.StatusCreated, map[string]string{
+			"status": "ok",
+			"id":     name,
+		})
+	case errors.Is(err, upload.ErrUnknownCategory):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Неизвестный тип загрузки",
+		})
+	case errors.Is(err, upload.ErrFileType):
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{
+			"error": "Недопустимый формат файла",
+		})
+	case errors.Is(err, upload.ErrTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": "Файл слишком большой",
+		})
+	case errors.As(err, &infected):
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "Файл отклонен проверкой безопасности",
+		})
+	case errors.Is(err, scanner.ErrUnavailable):
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Проверка файлов временно недоступна, попробуйте позже",
+		})
+	default:
+		slog.ErrorContext(c.Request().Context(), "Failed to store upload", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal Server Error")
+	}
+}
@@ -0,0 +1,110 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/health"
+)
+
+func TestReadyReportsEveryCheck(t *testing.T) {
+	c := health.New(time.Second)
+	c.Add("storage", func(context.Context) error { return nil })
+	c.Add("smtp", func(context.Context) error { return errors.New("connection refused") })
+
+	report := c.Ready(context.Background())
+	if report.OK() || report.Status != health.StatusFail {
+		t.Errorf("status = %q with a failing check, want %q", report.Status, health.StatusFail)
+	}
+	if report.Checks["storage"] != health.StatusOK {
+		t.Errorf("storage = %q, want ok", report.Checks["storage"])
+	}
+	if report.Checks["smtp"] != "connection refused" {
+		t.Errorf("smtp = %q, want the check's error", report.Checks["smtp"])
+	}
+}
+
+func TestReadyWithPassingChecks(t *testing.T) {
+	c := health.New(time.Second)
+	c.Add("storage", func(context.Context) error { return nil })
+
+	if report := c.Ready(context.Background()); !report.OK() {
+		t.Errorf("report = %+v, want ok", report)
+	}
+}
+
+func TestReadyTimesOutSlowChecks(t *testing.T) {
+	c := health.New(50 * time.Millisecond)
+	c.Add("hanging", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	report := c.Ready(context.Background())
+	if report.OK() {
+		t.Error("a check that never finished passed")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Ready took %v despite the timeout", elapsed)
+	}
+}
+
+func TestShutdownFailsReadiness(t *testing.T) {
+	c := health.New(time.Second)
+	c.Add("storage", func(context.Context) error { return nil })
+	c.Shutdown()
+
+	report := c.Ready(context.Background())
+	if report.OK() {
+		t.Fatal("ready after Shutdown")
+	}
+	if report.Checks["server"] != health.ErrShuttingDown.Error() {
+		t.Errorf("checks = %v, want the server shutting down", report.Checks)
+	}
+}
+
+func TestCachedRunsOncePerTTL(t *testing.T) {
+	var runs atomic.Int32
+	failure := errors.New("unavailable")
+	check := health.Cached(func(context.Context) error {
+		runs.Add(1)
+		return failure
+	}, 50*time.Millisecond)
+
+	for range 3 {
+		if err := check(context.Background()); !errors.Is(err, failure) {
+			t.Fatalf("check = %v, want the cached failure", err)
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("check ran %d times within the TTL, want 1", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := check(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("check = %v, want the failure", err)
+	}
+	if n := runs.Load(); n != 2 {
+		t.Errorf("check ran %d times after the TTL, want 2", n)
+	}
+}
+
+func TestWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := health.Writable(dir)(context.Background()); err != nil {
+		t.Fatalf("Writable = %v for a writable directory", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("probe files left behind: %v", entries)
+	}
+
+	if err := health.Writable(filepath.Join(dir, "missing"))(context.Background()); err == nil {
+		t.Error("Writable passed for a missing directory")
+	}
+}
@@ -0,0 +1,25 @@
+// Package locale picks the language of a response.
+package locale
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Supported lists the locales we have translations for; the first one is the default
+var Supported = []string{"ru", "en"}
+
+// FromRequest picks the best supported locale from the Accept-Language header
+func FromRequest(r *http.Request) string {
+	header := r.Header.Get("Accept-Language")
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		for _, locale := range Supported {
+			if lang == locale {
+				return locale
+			}
+		}
+	}
+	return Supported[0]
+}
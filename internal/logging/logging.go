@@ -0,0 +1,100 @@
+// Package logging provides slog handlers shared by the website's loggers.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configure the root logger
+type Options struct {
+	// Format is FormatJSON or FormatText
+	Format string
+	// Level is the minimum level: "debug", "info", "warn" or "error"
+	Level string
+	// Secrets are masked wherever they appear in log output
+	Secrets []string
+	// RedactKeys name attributes holding personal data, whose values are masked
+	RedactKeys []string
+}
+
+// New creates a logger writing to w that masks secrets and personal data and
+// adds request and trace IDs taken from the record's context
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", opts.Level, err)
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch opts.Format {
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, handlerOpts)
+	case FormatText:
+		h = slog.NewTextHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	h = NewRedactHandler(h, opts.Secrets, opts.RedactKeys)
+	return slog.New(NewContextHandler(h)), nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the request being served
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextHandler adds the request ID and the trace and span IDs of the
+// record's context to every record, so logs can be joined with traces
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps next with context attributes
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: next}
+}
+
+// Handle adds request_id, trace_id and span_id when ctx carries them
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs keeps the wrapper around the derived handler
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the wrapper around the derived handler
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
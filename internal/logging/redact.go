@@ -0,0 +1,159 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Redacted replaces masked values in log output
+const Redacted = "[REDACTED]"
+
+// minSubstringLength is the shortest secret masked wherever it appears.
+// Shorter secrets are too common inside ordinary words, so they are only
+// masked where they stand alone, delimited by non-alphanumeric characters.
+const minSubstringLength = 8
+
+// RedactHandler masks secret values wherever they appear in a record and the
+// values of attributes whose keys name personal data
+type RedactHandler struct {
+	next    slog.Handler
+	secrets []string
+	short   []string
+	keys    []string
+}
+
+// NewRedactHandler wraps next, masking every occurrence of secrets and the
+// values of attributes with the given keys, compared case-insensitively.
+// Email addresses keep their first letter and domain. Secrets shorter than
+// eight characters are masked only where they are not part of a longer word.
+func NewRedactHandler(next slog.Handler, secrets, keys []string) *RedactHandler {
+	h := &RedactHandler{next: next}
+	for _, s := range secrets {
+		switch {
+		case len(s) >= minSubstringLength:
+			h.secrets = append(h.secrets, s)
+		case s != "":
+			h.short = append(h.short, s)
+		}
+	}
+	for _, k := range keys {
+		h.keys = append(h.keys, strings.ToLower(k))
+	}
+	return h
+}
+
+// Enabled reports whether the wrapped handler handles the level
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle passes a masked copy of the record to the wrapped handler
+func (h *RedactHandler) Handle(ctx context.Context, record slog.Record) error {
+	masked := slog.NewRecord(record.Time, record.Level, h.mask(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		masked.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, masked)
+}
+
+// WithAttrs masks the attributes before handing them to the wrapped handler
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		masked[i] = h.redact(a)
+	}
+	return &RedactHandler{next: h.next.WithAttrs(masked), secrets: h.secrets, short: h.short, keys: h.keys}
+}
+
+// WithGroup keeps the wrapper around the derived handler
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{next: h.next.WithGroup(name), secrets: h.secrets, short: h.short, keys: h.keys}
+}
+
+func (h *RedactHandler) redact(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if slices.Contains(h.keys, strings.ToLower(a.Key)) && a.Value.Kind() != slog.KindGroup {
+		if strings.EqualFold(a.Key, "email") {
+			return slog.String(a.Key, maskEmail(a.Value.String()))
+		}
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.mask(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		masked := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			masked[i] = h.redact(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(masked...)}
+	case slog.KindAny:
+		// Errors and other values are checked in the form they are printed in
+		text := fmt.Sprint(a.Value.Any())
+		if masked := h.mask(text); masked != text {
+			return slog.String(a.Key, masked)
+		}
+	}
+	return a
+}
+
+// mask replaces every secret in s
+func (h *RedactHandler) mask(s string) string {
+	for _, secret := range h.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	for _, secret := range h.short {
+		s = replaceWord(s, secret)
+	}
+	return s
+}
+
+// replaceWord replaces the occurrences of word in s that are not preceded or
+// followed by a letter or digit
+func replaceWord(s, word string) string {
+	var b strings.Builder
+	last := 0
+	for from := 0; from <= len(s)-len(word); {
+		i := strings.Index(s[from:], word)
+		if i < 0 {
+			break
+		}
+		start, end := from+i, from+i+len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if (start > 0 && isWordRune(before)) || (end < len(s) && isWordRune(after)) {
+			from = start + 1
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Redacted)
+		last, from = end, end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// maskEmail keeps the first letter and the domain of an address
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return Redacted
+	}
+	return string([]rune(local)[0]) + "***@" + domain
+}
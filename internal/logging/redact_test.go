@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+const (
+	longSecret  = "123456:ABC-bot-token"
+	shortSecret = "pw1"
+	email       = "alice@example.com"
+)
+
+func newTestLogger(t *testing.T, format string) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{
+		Format:     format,
+		Level:      "debug",
+		Secrets:    []string{longSecret, shortSecret, ""},
+		RedactKeys: []string{"email", "name"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return logger, &buf
+}
+
+func TestRedactHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(*slog.Logger)
+	}{
+		{"message", func(l *slog.Logger) {
+			l.Info("calling https://api.telegram.org/bot" + longSecret + "/sendMessage with " + shortSecret)
+		}},
+		{"string attr", func(l *slog.Logger) {
+			l.Info("event", "token", longSecret, "password", shortSecret, "email", email)
+		}},
+		{"error attr", func(l *slog.Logger) {
+			l.Error("event", "error", errors.New("post /bot"+longSecret+": auth "+shortSecret+" rejected"))
+		}},
+		{"nested group", func(l *slog.Logger) {
+			l.Info("event", slog.Group("outer",
+				slog.String("password", shortSecret),
+				slog.Group("inner", slog.String("email", email), slog.String("url", "/bot"+longSecret)),
+			))
+		}},
+		{"with attrs", func(l *slog.Logger) {
+			l.With("email", email, "password", shortSecret, "token", longSecret).Info("event")
+		}},
+		{"with group", func(l *slog.Logger) {
+			l.WithGroup("request").With("email", email).Info("event", "token", longSecret, "password", shortSecret)
+		}},
+		{"with attrs group", func(l *slog.Logger) {
+			l.With(slog.Group("user", slog.String("EMAIL", email), slog.String("secret", shortSecret))).Info("event")
+		}},
+	}
+
+	for _, format := range []string{FormatJSON, FormatText} {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				logger, buf := newTestLogger(t, format)
+				tt.log(logger)
+
+				out := buf.String()
+				for _, leaked := range []string{longSecret, "=" + shortSecret, `"` + shortSecret, " " + shortSecret, email} {
+					if strings.Contains(out, leaked) {
+						t.Errorf("output contains %q:\n%s", leaked, out)
+					}
+				}
+				if !strings.Contains(out, Redacted) && !strings.Contains(out, "a***@example.com") {
+					t.Errorf("output has nothing masked:\n%s", out)
+				}
+			})
+		}
+	}
+}
+
+func TestRedactHandlerMasksEmails(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatText} {
+		t.Run(format, func(t *testing.T) {
+			logger, buf := newTestLogger(t, format)
+			logger.Info("event", "email", email, "name", "Alice")
+
+			out := buf.String()
+			if !strings.Contains(out, "a***@example.com") {
+				t.Errorf("email not partially masked:\n%s", out)
+			}
+			if strings.Contains(out, "Alice") {
+				t.Errorf("name not masked:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestRedactHandlerKeepsWordsContainingShortSecrets(t *testing.T) {
+	logger, buf := newTestLogger(t, FormatJSON)
+	logger.Info("pw12 and xpw1 are not the password", "value", "pw1x")
+
+	out := buf.String()
+	for _, kept := range []string{"pw12", "xpw1", "pw1x"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("output lost %q:\n%s", kept, out)
+		}
+	}
+	if strings.Contains(out, Redacted) {
+		t.Errorf("output masked a word containing a secret:\n%s", out)
+	}
+}
+
+func TestReplaceWord(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"pw1", Redacted},
+		{"pass=pw1&x", "pass=" + Redacted + "&x"},
+		{"pw1pw1", "pw1pw1"},
+		{"apw1 pw1", "apw1 " + Redacted},
+		{"(pw1)", "(" + Redacted + ")"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := replaceWord(tt.in, "pw1"); got != tt.want {
+			t.Errorf("replaceWord(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
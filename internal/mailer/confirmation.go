@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lexfrei/ne-stat-toboy/web/template"
+)
+
+// Confirmation is the auto-reply sent to people who write through the contact form
+type Confirmation struct {
+	SubmissionID string
+	Locale       string
+	Name         string
+	Message      string
+	ConfirmURL   string
+}
+
+// confirmationStrings holds the localized texts of the confirmation email
+type confirmationStrings struct {
+	Subject      string
+	Greeting     string
+	Intro        string
+	MessageLabel string
+	Action       string
+	Footer       string
+}
+
+var confirmationTexts = map[string]confirmationStrings{
+	"ru": {
+		Subject:      "Мы получили ваше сообщение — Не стать тобой",
+		Greeting:     "Здравствуйте, %s!",
+		Intro:        "Спасибо, что написали нам. Пожалуйста, подтвердите адрес электронной почты, чтобы мы могли вам ответить.",
+		MessageLabel: "Ваше сообщение:",
+		Action:       "Подтвердить адрес",
+		Footer:       "Если вы не отправляли это сообщение, просто проигнорируйте письмо.",
+	},
+	"en": {
+		Subject:      "We received your message — Ne Stat Toboy",
+		Greeting:     "Hello, %s!",
+		Intro:        "Thank you for writing to us. Please confirm your email address so that we can reply.",
+		MessageLabel: "Your message:",
+		Action:       "Confirm address",
+		Footer:       "If you did not send this message, just ignore this email.",
+	},
+}
+
+// Render builds the localized HTML and plain-text email for the recipient
+func (c Confirmation) Render(ctx context.Context, to string) (Message, error) {
+	texts, ok := confirmationTexts[c.Locale]
+	if !ok {
+		c.Locale = "ru"
+		texts = confirmationTexts["ru"]
+	}
+
+	data := template.EmailData{
+		Lang:         c.Locale,
+		Title:        texts.Subject,
+		Greeting:     fmt.Sprintf(texts.Greeting, c.Name),
+		Intro:        texts.Intro,
+		MessageLabel: texts.MessageLabel,
+		Message:      c.Message,
+		Action:       texts.Action,
+		ActionURL:    c.ConfirmURL,
+		Footer:       texts.Footer,
+	}
+
+	var html bytes.Buffer
+	if err := template.Email(data).Render(ctx, &html); err != nil {
+		return Message{}, fmt.Errorf("failed to render confirmation email: %w", err)
+	}
+
+	return Message{
+		To:       to,
+		Subject:  texts.Subject,
+		Text:     plainText(data),
+		HTML:     html.String(),
+		ThreadID: threadID(c.SubmissionID),
+	}, nil
+}
+
+func plainText(data template.EmailData) string {
+	var b strings.Builder
+	b.WriteString(data.Greeting + "\n\n")
+	b.WriteString(data.Intro + "\n\n")
+	b.WriteString(data.MessageLabel + "\n")
+	for _, line := range strings.Split(data.Message, "\n") {
+		b.WriteString("> " + line + "\n")
+	}
+	if data.ActionURL != "" {
+		b.WriteString("\n" + data.Action + ": " + data.ActionURL + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString("-- \n" + data.Footer + "\n")
+	return b.String()
+}
+
+// threadID is the mail thread identifier shared by all emails about a submission
+func threadID(submissionID string) string {
+	if submissionID == "" {
+		return ""
+	}
+	return "contact-" + submissionID
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter allows at most one email per address within the interval
+type RateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewRateLimiter creates a per-address rate limiter
+func NewRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		interval: interval,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// Allow reports whether an email may be sent to address now and records the attempt
+func (l *RateLimiter) Allow(address string) bool {
+	key := strings.ToLower(strings.TrimSpace(address))
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop expired entries so the map does not grow without bound
+	for addr, t := range l.last {
+		if now.Sub(t) >= l.interval {
+			delete(l.last, addr)
+		}
+	}
+
+	if _, ok := l.last[key]; ok {
+		return false
+	}
+
+	l.last[key] = now
+	return true
+}
@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/lexfrei/ne-stat-toboy/web/template"
+)
+
+// Reply is an answer from the film team to a contact form submission
+type Reply struct {
+	SubmissionID string
+	Locale       string
+	Name         string
+	Original     string
+	Answer       string
+}
+
+// replyStrings holds the localized texts of the reply email
+type replyStrings struct {
+	Subject      string
+	Greeting     string
+	MessageLabel string
+	Footer       string
+}
+
+var replyTexts = map[string]replyStrings{
+	"ru": {
+		Subject:      "Re: Ваше сообщение — Не стать тобой",
+		Greeting:     "Здравствуйте, %s!",
+		MessageLabel: "Ваше сообщение:",
+		Footer:       "Команда фильма «Не стать тобой»",
+	},
+	"en": {
+		Subject:      "Re: Your message — Ne Stat Toboy",
+		Greeting:     "Hello, %s!",
+		MessageLabel: "Your message:",
+		Footer:       "The team of «Ne Stat Toboy»",
+	},
+}
+
+// Render builds the localized HTML and plain-text reply for the recipient
+func (r Reply) Render(ctx context.Context, to string) (Message, error) {
+	texts, ok := replyTexts[r.Locale]
+	if !ok {
+		r.Locale = "ru"
+		texts = replyTexts["ru"]
+	}
+
+	data := template.EmailData{
+		Lang:         r.Locale,
+		Title:        texts.Subject,
+		Greeting:     fmt.Sprintf(texts.Greeting, r.Name),
+		Intro:        r.Answer,
+		MessageLabel: texts.MessageLabel,
+		Message:      r.Original,
+		Footer:       texts.Footer,
+	}
+
+	var html bytes.Buffer
+	if err := template.Email(data).Render(ctx, &html); err != nil {
+		return Message{}, fmt.Errorf("failed to render reply email: %w", err)
+	}
+
+	return Message{
+		To:       to,
+		Subject:  texts.Subject,
+		Text:     plainText(data),
+		HTML:     html.String(),
+		ThreadID: threadID(r.SubmissionID),
+		Reply:    true,
+	}, nil
+}
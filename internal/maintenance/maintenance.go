@@ -0,0 +1,19 @@
+// Package maintenance holds the site-wide maintenance switch.
+package maintenance
+
+import "sync/atomic"
+
+// Mode reports whether the public site is in maintenance
+type Mode struct {
+	enabled atomic.Bool
+}
+
+// Enabled reports whether maintenance mode is on
+func (m *Mode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Set turns maintenance mode on or off
+func (m *Mode) Set(enabled bool) {
+	m.enabled.Store(enabled)
+}
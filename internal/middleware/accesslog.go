@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AccessLogMiddleware logs every request through slog, at Warn for client
+// errors and Error for server errors. Only sampleRate of the successful
+// requests are logged; health probes and metrics scrapes are skipped.
+func AccessLogMiddleware(sampleRate float64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if IsMonitoring(c.Request().URL.Path) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// Let the error handler write the response so its status is
+				// logged. The error is handled here and not returned, so that
+				// outer middlewares do not handle it again; the server span
+				// keeps it as an attribute.
+				c.Error(err)
+				trace.SpanFromContext(c.Request().Context()).SetAttributes(attribute.String("echo.error", err.Error()))
+			}
+			latency := time.Since(start)
+
+			request := c.Request()
+			response := c.Response()
+			status := response.Status
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			case status < http.StatusMultipleChoices && sampleRate < 1 && rand.Float64() >= sampleRate:
+				return nil
+			}
+
+			attrs := []slog.Attr{
+				slog.String("remote_ip", c.RealIP()),
+				slog.String("host", request.Host),
+				slog.String("method", request.Method),
+				// The query is left out: it carries confirmation tokens
+				slog.String("path", request.URL.Path),
+				slog.String("user_agent", request.UserAgent()),
+				slog.Int("status", status),
+				slog.Duration("latency", latency),
+				slog.Int64("bytes_in", max(request.ContentLength, 0)),
+				slog.Int64("bytes_out", response.Size),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			slog.LogAttrs(request.Context(), level, "HTTP Request", attrs...)
+
+			return nil
+		}
+	}
+}
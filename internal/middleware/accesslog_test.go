@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// captureLogs sends slog output to a buffer of JSON records for the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var list []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		list = append(list, record)
+	}
+	return list
+}
+
+func TestAccessLogHandlesErrorsOnce(t *testing.T) {
+	buf := captureLogs(t)
+
+	e := echo.New()
+	var handled int
+	// An error handler that, unlike echo's default one, does not check
+	// whether the response was already written
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled++
+		_ = c.String(http.StatusTeapot, "handled: "+err.Error())
+	}
+	// Outer middlewares, such as the tracing one, handle returned errors too
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			return err
+		}
+	})
+	e.Use(AccessLogMiddleware(1))
+	e.GET("/fail", func(echo.Context) error {
+		return errors.New("broken")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	if handled != 1 {
+		t.Errorf("error handled %d times, want once", handled)
+	}
+	if rec.Code != http.StatusTeapot || rec.Body.String() != "handled: broken" {
+		t.Errorf("response = %d %q, want a single error response", rec.Code, rec.Body.String())
+	}
+
+	logged := records(t, buf)
+	if len(logged) != 1 {
+		t.Fatalf("%d records logged, want 1", len(logged))
+	}
+	if logged[0]["level"] != "WARN" || logged[0]["status"] != float64(http.StatusTeapot) || logged[0]["error"] != "broken" {
+		t.Errorf("access log = %v, want the status written by the error handler", logged[0])
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	buf := captureLogs(t)
+
+	e := echo.New()
+	e.Use(AccessLogMiddleware(0))
+	e.GET("/ok", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	e.GET("/broken", func(echo.Context) error { return errors.New("broken") })
+
+	for _, target := range []string{"/ok", "/healthz", "/missing", "/broken"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target+"?token=secret", nil))
+	}
+
+	logged := records(t, buf)
+	if len(logged) != 2 {
+		t.Fatalf("%d records logged, want only the two failures: %v", len(logged), logged)
+	}
+	if logged[0]["path"] != "/missing" || logged[0]["level"] != "WARN" {
+		t.Errorf("client error logged as %v", logged[0])
+	}
+	if logged[1]["path"] != "/broken" || logged[1]["level"] != "ERROR" {
+		t.Errorf("server error logged as %v", logged[1])
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Error("query string logged")
+	}
+}
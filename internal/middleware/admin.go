@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// AdminAuth protects admin endpoints with HTTP basic auth
+func AdminAuth(username, password string) echo.MiddlewareFunc {
+	return echoMiddleware.BasicAuthWithConfig(echoMiddleware.BasicAuthConfig{
+		Realm: "admin",
+		Validator: func(user, pass string, _ echo.Context) (bool, error) {
+			userOK := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
+			passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+			return userOK && passOK, nil
+		},
+	})
+}
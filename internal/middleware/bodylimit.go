@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// BodyLimitMiddleware answers requests whose body exceeds limit with 413.
+// Routes maps routes, as registered, to their own limits, so that a route
+// gets its limit whatever form of the path reached it. Limits are sizes such
+// as "64K" or "1M"; it must be installed before anything reads the body.
+func BodyLimitMiddleware(limit string, routes map[string]string) echo.MiddlewareFunc {
+	byPath := make(map[string]echo.MiddlewareFunc, len(routes))
+	for path, routeLimit := range routes {
+		byPath[path] = echoMiddleware.BodyLimit(routeLimit)
+	}
+	fallback := echoMiddleware.BodyLimit(limit)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		limited := make(map[string]echo.HandlerFunc, len(byPath))
+		for path, mw := range byPath {
+			limited[path] = mw(next)
+		}
+		other := fallback(next)
+
+		return func(c echo.Context) error {
+			if h, ok := limited[c.Path()]; ok {
+				return h(c)
+			}
+			return other(c)
+		}
+	}
+}
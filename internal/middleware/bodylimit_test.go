@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+func TestBodyLimitPerRoute(t *testing.T) {
+	e := echo.New()
+	e.Pre(echoMiddleware.RemoveTrailingSlash())
+	e.Use(BodyLimitMiddleware("1024", map[string]string{
+		"/api/contact":   "64",
+		"/api/films/:id": "64",
+	}))
+	echoBody := func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, strconv.Itoa(len(body)))
+	}
+	e.POST("/api/contact", echoBody)
+	e.POST("/api/films/:id", echoBody)
+	e.POST("/api/upload", echoBody)
+
+	tests := []struct {
+		name    string
+		target  string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"small form", "/api/contact", 64, false, http.StatusOK},
+		{"large form", "/api/contact", 65, false, http.StatusRequestEntityTooLarge},
+		{"large form with a query", "/api/contact?lang=en", 65, false, http.StatusRequestEntityTooLarge},
+		{"large form with a trailing slash", "/api/contact/", 65, false, http.StatusRequestEntityTooLarge},
+		{"large form with an escaped path", "/api/contac%74", 65, false, http.StatusNotFound},
+		{"large streamed form", "/api/contact", 65, true, http.StatusRequestEntityTooLarge},
+		{"large form to a parameterised route", "/api/films/42", 65, false, http.StatusRequestEntityTooLarge},
+		{"other route within the default", "/api/upload", 1024, false, http.StatusOK},
+		{"other route over the default", "/api/upload", 1025, false, http.StatusRequestEntityTooLarge},
+		{"unknown route over the default", "/missing", 1025, false, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(strings.Repeat("a", tt.size))
+			if tt.chunked {
+				// Without a length the limit applies while reading
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.target, body)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
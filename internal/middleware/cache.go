@@ -5,9 +5,12 @@ import (
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
 )
 
-// CacheControlMiddleware adds cache control headers for Cloudflare
+// CacheControlMiddleware adds cache control headers for Cloudflare. Static
+// files are cached at the edge, and so are pages unless consent personalizes
+// them.
 func CacheControlMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -18,24 +21,45 @@ func CacheControlMiddleware() echo.MiddlewareFunc {
 			case strings.HasPrefix(path, "/static/"):
 				ext := filepath.Ext(path)
 				switch ext {
-				case ".css", ".js", ".jpg", ".jpeg", ".png", ".gif", ".ico", ".svg":
+				case ".css", ".js":
+					// Fingerprinted URLs are made immutable by the asset handler;
+					// plain names change on deploy and are cached briefly (1 hour)
+					c.Response().Header().Set("Cache-Control", "public, max-age=3600, s-maxage=3600")
+					c.Response().Header().Set("CDN-Cache-Control", "max-age=3600")
+					c.Response().Header().Set("Cloudflare-CDN-Cache-Control", "max-age=3600")
+				case ".jpg", ".jpeg", ".png", ".gif", ".ico", ".svg", ".webp":
 					// Long cache for static assets (30 days)
 					c.Response().Header().Set("Cache-Control", "public, max-age=2592000, s-maxage=2592000, stale-while-revalidate=86400")
 					// Cloudflare specific cache headers
 					c.Response().Header().Set("CDN-Cache-Control", "max-age=2592000")
 					c.Response().Header().Set("Cloudflare-CDN-Cache-Control", "max-age=2592000")
 				}
+			case strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/contact/confirm") ||
+				strings.HasPrefix(path, "/admin/"):
+				// No cache for API calls, one-time verification links and personal data
+				c.Response().Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, proxy-revalidate")
+				c.Response().Header().Set("Pragma", "no-cache")
+				c.Response().Header().Set("Expires", "0")
 			case path == "/" || strings.HasPrefix(path, "/about") || strings.HasPrefix(path, "/team") || 
-				strings.HasPrefix(path, "/locations") || strings.HasPrefix(path, "/contact"):
+				strings.HasPrefix(path, "/locations") || strings.HasPrefix(path, "/contact") ||
+				strings.HasPrefix(path, "/privacy"):
+				if strings.HasPrefix(path, "/contact") {
+					// The contact form carries a per-visitor CSRF token
+					c.Response().Header().Set("Cache-Control", "private, no-cache")
+					break
+				}
+				if consent.Personalized() {
+					// Third-party tags depend on the consent cookie, so shared caches
+					// must not keep pages; browsers revalidate and usually get a 304
+					// from the page cache
+					c.Response().Header().Set("Cache-Control", "private, no-cache")
+					addVary(c.Response().Header(), echo.HeaderCookie)
+					break
+				}
 				// Short cache for HTML pages (5 minutes)
 				c.Response().Header().Set("Cache-Control", "public, max-age=300, s-maxage=300, stale-while-revalidate=900")
 				c.Response().Header().Set("CDN-Cache-Control", "max-age=300")
 				c.Response().Header().Set("Cloudflare-CDN-Cache-Control", "max-age=300")
-			case strings.HasPrefix(path, "/api/"):
-				// No cache for API calls
-				c.Response().Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, proxy-revalidate")
-				c.Response().Header().Set("Pragma", "no-cache")
-				c.Response().Header().Set("Expires", "0")
 			}
 			
 			return next(c)
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+)
+
+func cacheHeaders(t *testing.T, path string) http.Header {
+	t.Helper()
+
+	e := echo.New()
+	e.Use(CacheControlMiddleware())
+	e.GET("/*", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, "<p>page</p>")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Header()
+}
+
+func TestCacheControlFollowsConsent(t *testing.T) {
+	// Tags cannot be unregistered, so the shared case goes first
+	t.Run("shared pages", func(t *testing.T) {
+		header := cacheHeaders(t, "/about")
+		if got := header.Get("Cache-Control"); got != "public, max-age=300, s-maxage=300, stale-while-revalidate=900" {
+			t.Errorf("Cache-Control = %q, want pages cached at the edge", got)
+		}
+		if got := header.Get("CDN-Cache-Control"); got != "max-age=300" {
+			t.Errorf("CDN-Cache-Control = %q", got)
+		}
+		if slices.Contains(header.Values(echo.HeaderVary), echo.HeaderCookie) {
+			t.Error("shared page varies on Cookie")
+		}
+	})
+
+	t.Run("contact form", func(t *testing.T) {
+		if got := cacheHeaders(t, "/contact").Get("Cache-Control"); got != "private, no-cache" {
+			t.Errorf("Cache-Control = %q, want the CSRF-protected form kept private", got)
+		}
+	})
+
+	t.Run("personalized pages", func(t *testing.T) {
+		consent.Register(consent.Tag{Category: consent.Analytics, Src: "https://tags.example/tag.js"})
+
+		header := cacheHeaders(t, "/about")
+		if got := header.Get("Cache-Control"); got != "private, no-cache" {
+			t.Errorf("Cache-Control = %q, want personalized pages kept out of shared caches", got)
+		}
+		if got := header.Get("CDN-Cache-Control"); got != "" {
+			t.Errorf("CDN-Cache-Control = %q on a personalized page", got)
+		}
+		if !slices.Contains(header.Values(echo.HeaderVary), echo.HeaderCookie) {
+			t.Error("personalized page does not vary on Cookie")
+		}
+	})
+}
+
+func TestCacheControlOfStaticAndPrivateRoutes(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/static/css/style.css", "public, max-age=3600, s-maxage=3600"},
+		{"/static/img/poster.jpg", "public, max-age=2592000, s-maxage=2592000, stale-while-revalidate=86400"},
+		{"/api/contact", "no-store, no-cache, must-revalidate, proxy-revalidate"},
+		{"/contact/confirm", "no-store, no-cache, must-revalidate, proxy-revalidate"},
+		{"/admin/analytics", "no-store, no-cache, must-revalidate, proxy-revalidate"},
+	}
+	for _, tt := range tests {
+		if got := cacheHeaders(t, tt.path).Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s: Cache-Control = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
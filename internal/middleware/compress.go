@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/minify"
+)
+
+var (
+	brotliPool = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, 5) }}
+	gzipPool   = sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}
+)
+
+// compressibleTypes are the media types compressed at runtime besides text/*
+var compressibleTypes = map[string]bool{
+	"application/javascript": true,
+	"application/json":       true,
+	"application/xml":        true,
+	"image/svg+xml":          true,
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+	encoder  io.WriteCloser
+	decided  bool
+}
+
+// decide enables compression if the response is worth compressing
+func (w *compressResponseWriter) decide(code int, firstChunk []byte) {
+	w.decided = true
+
+	header := w.Header()
+	if header.Get(echo.HeaderContentType) == "" && firstChunk != nil {
+		// Sniff before compressing, net/http would sniff the compressed bytes
+		header.Set(echo.HeaderContentType, http.DetectContentType(firstChunk))
+	}
+
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified ||
+		header.Get(echo.HeaderContentEncoding) != "" || !compressibleType(header.Get(echo.HeaderContentType)) {
+		return
+	}
+
+	header.Set(echo.HeaderContentEncoding, w.encoding)
+	addVary(header, echo.HeaderAcceptEncoding)
+	header.Del(echo.HeaderContentLength)
+
+	switch w.encoding {
+	case "br":
+		bw := brotliPool.Get().(*brotli.Writer)
+		bw.Reset(w.ResponseWriter)
+		w.encoder = bw
+	case "gzip":
+		gw := gzipPool.Get().(*gzip.Writer)
+		gw.Reset(w.ResponseWriter)
+		w.encoder = gw
+	}
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.decide(code, nil)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.decide(http.StatusOK, b)
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressResponseWriter) Flush() {
+	if f, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("response writer does not support hijacking")
+}
+
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close flushes the encoder and returns it to its pool
+func (w *compressResponseWriter) close() error {
+	if w.encoder == nil {
+		return nil
+	}
+
+	err := w.encoder.Close()
+	switch e := w.encoder.(type) {
+	case *brotli.Writer:
+		e.Reset(io.Discard)
+		brotliPool.Put(e)
+	case *gzip.Writer:
+		e.Reset(io.Discard)
+		gzipPool.Put(e)
+	}
+	w.encoder = nil
+
+	return err
+}
+
+// CompressMiddleware compresses dynamic text responses with Brotli or Gzip.
+// Static files are skipped: the asset handler serves precompressed variants.
+func CompressMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			if request.Method == http.MethodHead || strings.HasPrefix(request.URL.Path, "/static/") {
+				return next(c)
+			}
+
+			encoding := preferredEncoding(request.Header.Get(echo.HeaderAcceptEncoding))
+			if encoding == "" {
+				return next(c)
+			}
+
+			response := c.Response()
+			original := response.Writer
+			cw := &compressResponseWriter{ResponseWriter: original, encoding: encoding}
+			response.Writer = cw
+
+			err := next(c)
+
+			if closeErr := cw.close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+			// Let the error handler write an uncompressed response if nothing was sent
+			response.Writer = original
+
+			return err
+		}
+	}
+}
+
+// runtimeEncodings are the encodings applied at runtime, in order of preference
+var runtimeEncodings = []string{"br", "gzip"}
+
+// preferredEncoding returns "br" or "gzip", whichever the client weights
+// higher, or "" if it accepts neither
+func preferredEncoding(acceptEncoding string) string {
+	return minify.NegotiateNames(acceptEncoding, runtimeEncodings)
+}
+
+func compressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleTypes[mediaType]
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+)
+
+// ConsentMiddleware passes the visitor's consent choice to templ components
+// through the request context. Unless pages are personalized, the choice is
+// not read at all, so that every visitor gets the same page.
+func ConsentMiddleware(manager *consent.Manager) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := consent.WithVersion(c.Request().Context(), manager.Version())
+			if consent.Personalized() {
+				ctx = consent.WithChoice(ctx, manager.Read(c.Request()))
+			}
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/csp"
+)
+
+// CSPMiddleware sets the Content-Security-Policy header with a fresh script nonce
+// and passes the nonce to templ components through the request context.
+// A non-nil reportOnly policy is sent as Content-Security-Policy-Report-Only
+// with the same nonce, so that a tighter policy can be tried before enforcing it.
+func CSPMiddleware(policy, reportOnly *csp.Policy) echo.MiddlewareFunc {
+	reportURI := policy.ReportURI()
+	if reportOnly != nil && reportURI == "" {
+		reportURI = reportOnly.ReportURI()
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			nonce, err := csp.NewNonce()
+			if err != nil {
+				return err
+			}
+
+			header := c.Response().Header()
+			header.Set(echo.HeaderContentSecurityPolicy, policy.Header(nonce))
+			if reportOnly != nil {
+				header.Set(echo.HeaderContentSecurityPolicyReportOnly, reportOnly.Header(nonce))
+			}
+			if reportURI != "" {
+				header.Set("Reporting-Endpoints", csp.ReportEndpointName+`="`+reportURI+`"`)
+			}
+			c.SetRequest(c.Request().WithContext(templ.WithNonce(c.Request().Context(), nonce)))
+
+			return next(c)
+		}
+	}
+}
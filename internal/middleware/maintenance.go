@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/maintenance"
+	"github.com/lexfrei/ne-stat-toboy/web/template"
+)
+
+// MaintenanceMiddleware answers public pages with 503 while maintenance mode is on.
+// Health checks, metrics, static files and the Telegram webhook keep working.
+func MaintenanceMiddleware(mode *maintenance.Mode) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			if !mode.Enabled() || IsMonitoring(path) ||
+				strings.HasPrefix(path, "/static/") || strings.HasPrefix(path, "/telegram/") {
+				return next(c)
+			}
+
+			c.Response().Header().Set("Retry-After", "600")
+			c.Response().Header().Set("Cache-Control", "no-store")
+
+			if strings.HasPrefix(path, "/api/") {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{
+					"error": "maintenance",
+				})
+			}
+
+			c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+			c.Response().WriteHeader(http.StatusServiceUnavailable)
+			return template.Maintenance().Render(c.Request().Context(), c.Response().Writer)
+		}
+	}
+}
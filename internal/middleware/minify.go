@@ -2,11 +2,13 @@
 package middleware
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"mime"
+	"net"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -17,116 +19,182 @@ import (
 	"github.com/tdewolff/minify/v2/css"
 	"github.com/tdewolff/minify/v2/html"
 	"github.com/tdewolff/minify/v2/js"
+	"github.com/tdewolff/minify/v2/json"
 	"github.com/tdewolff/minify/v2/svg"
 )
 
+// minifyMaxSize is the largest response body buffered for minification;
+// bigger responses are passed through unchanged
+const minifyMaxSize = 1 << 20
+
+// minifyResponseWriter holds back the status and body until the handler is done
 type minifyResponseWriter struct {
-	io.Writer
 	http.ResponseWriter
-	minifier *minify.M
-	mediaType string
+	buf         bytes.Buffer
+	status      int
+	passthrough bool
 }
 
-func (mrw *minifyResponseWriter) Write(b []byte) (int, error) {
-	if mrw.minifier != nil && mrw.mediaType != "" {
-		mb := &bytes.Buffer{}
-		if err := mrw.minifier.Minify(mrw.mediaType, mb, bytes.NewReader(b)); err != nil {
-			slog.Error("Failed to minify response", "error", err, "contentType", mrw.mediaType)
-			return mrw.ResponseWriter.Write(b)
+func (w *minifyResponseWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *minifyResponseWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+
+	if w.buf.Len()+len(b) > minifyMaxSize {
+		if err := w.startPassthrough(); err != nil {
+			return 0, err
 		}
-		
-		minified := mb.Bytes()
-		slog.Debug("Minified response", 
-			"contentType", mrw.mediaType, 
-			"originalSize", len(b), 
-			"minifiedSize", len(minified),
-			"reduction", fmt.Sprintf("%.2f%%", (1-float64(len(minified))/float64(len(b)))*100),
-		)
-		
-		// Update content length
-		mrw.ResponseWriter.Header().Set(echo.HeaderContentLength, strconv.Itoa(len(minified)))
-		return mrw.ResponseWriter.Write(minified)
+		return w.ResponseWriter.Write(b)
+	}
+
+	return w.buf.Write(b)
+}
+
+// Flush is deferred until the response is complete while buffering;
+// templ flushes after every render, so honoring it would disable minification
+func (w *minifyResponseWriter) Flush() {
+	if !w.passthrough {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *minifyResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("response writer does not support hijacking")
+}
+
+func (w *minifyResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// startPassthrough writes out what was held back and stops buffering
+func (w *minifyResponseWriter) startPassthrough() error {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.statusOrOK())
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *minifyResponseWriter) statusOrOK() int {
+	if w.status == 0 {
+		return http.StatusOK
 	}
-	return mrw.ResponseWriter.Write(b)
+	return w.status
 }
 
-// MinifyMiddleware creates a middleware that minifies response bodies by content type.
+// finish minifies the buffered body if its type allows and writes the response
+func (w *minifyResponseWriter) finish(m *minify.M) error {
+	body := w.buf.Bytes()
+	header := w.Header()
+
+	if header.Get(echo.HeaderContentType) == "" && len(body) > 0 {
+		header.Set(echo.HeaderContentType, http.DetectContentType(body))
+	}
+
+	if mediaType := minifiableType(m, header); mediaType != "" && len(body) > 0 {
+		var out bytes.Buffer
+		if err := m.Minify(mediaType, &out, bytes.NewReader(body)); err != nil {
+			slog.Error("Failed to minify response", "error", err, "contentType", mediaType)
+		} else {
+			slog.Debug("Minified response",
+				"contentType", mediaType,
+				"originalSize", len(body),
+				"minifiedSize", out.Len(),
+				"reduction", fmt.Sprintf("%.2f%%", (1-float64(out.Len())/float64(len(body)))*100),
+			)
+			body = out.Bytes()
+		}
+	}
+
+	status := w.statusOrOK()
+	if status != http.StatusNoContent && status != http.StatusNotModified {
+		header.Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+	}
+	w.ResponseWriter.WriteHeader(status)
+	_, err := w.ResponseWriter.Write(body)
+	return err
+}
+
+// minifiableType returns the media type of the response if there is a minifier for it
+func minifiableType(m *minify.M, header http.Header) string {
+	if header.Get(echo.HeaderContentEncoding) != "" {
+		return ""
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get(echo.HeaderContentType))
+	if err != nil {
+		return ""
+	}
+
+	if _, _, minifier := m.Match(mediaType); minifier == nil {
+		return ""
+	}
+	return mediaType
+}
+
+// MinifyMiddleware minifies HTML, CSS, JS, JSON and SVG responses. The whole
+// body (up to minifyMaxSize) is buffered so that it is minified in one piece
+// and sent with a correct Content-Length. Register it after the compression
+// middleware so that the minified body is what gets compressed.
 func MinifyMiddleware() echo.MiddlewareFunc {
 	m := minify.New()
 	m.AddFunc("text/html", html.Minify)
 	m.AddFunc("text/css", css.Minify)
 	m.AddFunc("application/javascript", js.Minify)
+	m.AddFunc("application/json", json.Minify)
 	m.AddFuncRegexp(regexp.MustCompile("^image/svg\\+xml$"), svg.Minify)
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			request := c.Request()
-			response := c.Response()
-			
-			// Skip minification for non-GET/POST methods or if header indicates skipping
+
+			// Skip minification for methods without a body worth minifying
 			if request.Method != http.MethodGet && request.Method != http.MethodPost {
 				return next(c)
 			}
-			
-			// Wait for headers to be written
-			resWriterBefore := response.Writer
-			
-			// Process the request
+
+			// Static files are minified ahead of time and must match their SRI hashes
+			if strings.HasPrefix(request.URL.Path, "/static/") {
+				return next(c)
+			}
+
+			response := c.Response()
+			original := response.Writer
+			mw := &minifyResponseWriter{ResponseWriter: original}
+			response.Writer = mw
+
 			err := next(c)
-			if err != nil {
+			response.Writer = original
+
+			if mw.passthrough {
 				return err
 			}
-			
-			// If the response writer has been changed, it means someone else has wrapped it
-			if response.Writer != resWriterBefore {
-				return nil
-			}
-			
-			// Get content type after handler has set headers
-			contentType := response.Header().Get(echo.HeaderContentType)
-			if contentType == "" {
-				return nil
-			}
-			
-			// Extract the base MIME type
-			mediaType, _, err := mime.ParseMediaType(contentType)
-			if err != nil {
-				slog.Error("Failed to parse content type", "error", err, "contentType", contentType)
-				return nil
-			}
-			
-			// Skip binary data, already compressed, or unsupported types
-			switch {
-			case strings.HasPrefix(mediaType, "image/") && mediaType != "image/svg+xml":
-				return nil
-			case strings.HasPrefix(mediaType, "audio/"):
-				return nil
-			case strings.HasPrefix(mediaType, "video/"):
-				return nil
-			case mediaType == "application/octet-stream":
-				return nil
-			case mediaType == "application/zip":
-				return nil
-			case mediaType == "application/x-gzip":
-				return nil
-			case mediaType == "application/pdf":
-				return nil
-			}
-			
-			// Check if minifier exists for this media type
-			if _, params, minifierFunc := m.Match(mediaType); minifierFunc == nil {
-				slog.Debug("No minifier for content type", "mediaType", mediaType, "params", params)
-				return nil
+
+			// Let the error handler write the response if the handler wrote nothing
+			if err != nil && mw.status == 0 && mw.buf.Len() == 0 {
+				return err
 			}
-			
-			// Wrap the response writer
-			response.Writer = &minifyResponseWriter{
-				ResponseWriter: response.Writer,
-				minifier: m,
-				mediaType: mediaType,
+
+			if finishErr := mw.finish(m); finishErr != nil && err == nil {
+				err = finishErr
 			}
-			
-			return nil
+			return err
 		}
 	}
-}
\ No newline at end of file
+}
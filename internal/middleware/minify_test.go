@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+const testPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>  Не стать тобой  </title>
+    <style>
+      body   {  color : red ;  }
+    </style>
+  </head>
+  <body>
+    <!-- navigation -->
+    <p class="lead">
+      Hello,   world
+    </p>
+    <script>
+      var   greeting = "hi" ;
+    </script>
+  </body>
+</html>
+`
+
+// serve runs a request through an echo instance with the given middlewares
+// and a handler answering with body as contentType
+func serve(t *testing.T, contentType, body string, header http.Header, middlewares ...echo.MiddlewareFunc) *httptest.ResponseRecorder {
+	t.Helper()
+
+	e := echo.New()
+	e.Use(middlewares...)
+	e.GET("/page", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, contentType, []byte(body))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/page", nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	return rec
+}
+
+func TestMinifyShrinksHTML(t *testing.T) {
+	rec := serve(t, echo.MIMETextHTMLCharsetUTF8, testPage, nil, MinifyMiddleware())
+
+	body := rec.Body.String()
+	if len(body) >= len(testPage) {
+		t.Fatalf("body not minified: %d bytes, original %d", len(body), len(testPage))
+	}
+	for _, kept := range []string{"Не стать тобой", "Hello, world", `class=lead`, "greeting"} {
+		if !strings.Contains(body, kept) {
+			t.Errorf("minified page lost %q:\n%s", kept, body)
+		}
+	}
+	if strings.Contains(body, "navigation") {
+		t.Errorf("comment kept in minified page:\n%s", body)
+	}
+
+	if got := rec.Header().Get(echo.HeaderContentLength); got != strconv.Itoa(len(body)) {
+		t.Errorf("Content-Length = %q, want %d", got, len(body))
+	}
+}
+
+func TestMinifyPassesThroughOtherTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"plain text", echo.MIMETextPlainCharsetUTF8, "  spaced    out \n\n text  "},
+		{"binary", "image/png", "\x89PNG\r\n\x1a\n  <html>  </html>  "},
+		{"csv", "text/csv", "a,  b\n c , d\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, tt.contentType, tt.body, nil, MinifyMiddleware())
+			if got := rec.Body.String(); got != tt.body {
+				t.Errorf("body changed: %q, want %q", got, tt.body)
+			}
+			if got := rec.Header().Get(echo.HeaderContentLength); got != strconv.Itoa(len(tt.body)) {
+				t.Errorf("Content-Length = %q, want %d", got, len(tt.body))
+			}
+		})
+	}
+}
+
+func TestMinifyPassesThroughOversizedResponses(t *testing.T) {
+	// Whitespace the minifier would remove, just over the buffering limit
+	body := "<html><body>" + strings.Repeat("<p>  a  </p>\n", minifyMaxSize/12) + "</body></html>"
+	if len(body) <= minifyMaxSize {
+		t.Fatalf("test body of %d bytes is not oversized", len(body))
+	}
+
+	rec := serve(t, echo.MIMETextHTMLCharsetUTF8, body, nil, MinifyMiddleware())
+	if rec.Body.Len() != len(body) || rec.Body.String() != body {
+		t.Errorf("oversized body changed: %d bytes, want %d", rec.Body.Len(), len(body))
+	}
+}
+
+func TestMinifyWithCompression(t *testing.T) {
+	plain := serve(t, echo.MIMETextHTMLCharsetUTF8, testPage, nil, MinifyMiddleware()).Body.String()
+
+	// Registered as in the router: compression wraps minification
+	rec := serve(t, echo.MIMETextHTMLCharsetUTF8, testPage,
+		http.Header{echo.HeaderAcceptEncoding: {"gzip"}},
+		CompressMiddleware(), MinifyMiddleware(),
+	)
+
+	if got := rec.Header().Get(echo.HeaderContentEncoding); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get(echo.HeaderContentLength); got != "" {
+		t.Errorf("Content-Length %q sent with a compressed body", got)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	decoded, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if string(decoded) != plain {
+		t.Errorf("decompressed body differs from the minified page:\n%s\nwant:\n%s", decoded, plain)
+	}
+}
@@ -0,0 +1,10 @@
+package middleware
+
+import "strings"
+
+// IsMonitoring reports whether path is a health probe or the metrics
+// endpoint, which are polled constantly and kept out of logs and traces
+func IsMonitoring(path string) bool {
+	return strings.HasPrefix(path, "/healthz") || strings.HasPrefix(path, "/livez") ||
+		strings.HasPrefix(path, "/readyz") || strings.HasPrefix(path, "/metrics")
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/consent"
+	"github.com/lexfrei/ne-stat-toboy/internal/locale"
+	"github.com/lexfrei/ne-stat-toboy/internal/minify"
+	"github.com/lexfrei/ne-stat-toboy/internal/pagecache"
+)
+
+// captureResponseWriter records a response instead of sending it
+type captureResponseWriter struct {
+	header http.Header
+	buf    bytes.Buffer
+	status int
+}
+
+func (w *captureResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *captureResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *captureResponseWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+// Flush is a no-op: the response is sent once rendering is done
+func (w *captureResponseWriter) Flush() {}
+
+// PageCacheMiddleware serves the routes it is attached to from cache. Pages
+// are rendered once per route, locale, consent choice and content version,
+// without a CSP nonce, and answered with 304 when the client already has
+// them. Only attach it to pages that are the same for every visitor:
+// /contact carries a per-request CSRF token and must not be cached.
+func PageCacheMiddleware(cache *pagecache.Cache) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			if request.Method != http.MethodGet && request.Method != http.MethodHead {
+				return next(c)
+			}
+
+			key := cache.Key(c.Path(), locale.FromRequest(request), consent.FromContext(request.Context()).Key())
+			page, ok := cache.Get(key)
+			if !ok {
+				rendered, err := render(c, next)
+				if err != nil || rendered == nil {
+					return err
+				}
+
+				page, err = cache.Put(key, rendered.header.Get(echo.HeaderContentType), rendered.buf.Bytes())
+				if err != nil {
+					slog.ErrorContext(c.Request().Context(), "Failed to cache page", "error", err, "key", key)
+					return c.Blob(http.StatusOK, rendered.header.Get(echo.HeaderContentType), rendered.buf.Bytes())
+				}
+				slog.DebugContext(c.Request().Context(), "Cached page", "key", key, "size", len(page.Body))
+			}
+
+			return servePage(c, page)
+		}
+	}
+}
+
+// render runs the handler into a buffer. It returns nil when the response
+// is not a cacheable page; the handler's response has then been sent as is.
+func render(c echo.Context, next echo.HandlerFunc) (*captureResponseWriter, error) {
+	request := c.Request()
+	response := c.Response()
+	original := response.Writer
+
+	capture := &captureResponseWriter{header: make(http.Header)}
+	response.Writer = capture
+	c.SetRequest(request.WithContext(templ.WithNonce(request.Context(), "")))
+
+	err := next(c)
+
+	response.Writer = original
+	c.SetRequest(request)
+
+	if capture.header.Get(echo.HeaderContentType) == "" && capture.buf.Len() > 0 {
+		capture.header.Set(echo.HeaderContentType, http.DetectContentType(capture.buf.Bytes()))
+	}
+	if capture.status == 0 && capture.buf.Len() > 0 {
+		capture.status = http.StatusOK
+	}
+
+	cacheable := err == nil &&
+		capture.status == http.StatusOK &&
+		strings.HasPrefix(capture.header.Get(echo.HeaderContentType), echo.MIMETextHTML)
+	if cacheable {
+		// Echo already counted the response as committed while capturing
+		response.Committed = false
+		return capture, nil
+	}
+
+	if err != nil && capture.buf.Len() == 0 {
+		return nil, err
+	}
+
+	for name, values := range capture.header {
+		response.Header()[name] = values
+	}
+	original.WriteHeader(capture.status)
+	_, writeErr := original.Write(capture.buf.Bytes())
+	if err == nil {
+		err = writeErr
+	}
+	return nil, err
+}
+
+// servePage writes the variant of page the client prefers, or 304 if it has it
+func servePage(c echo.Context, page *pagecache.Page) error {
+	request := c.Request()
+	header := c.Response().Header()
+
+	encoding := minify.Negotiate(request.Header.Get(echo.HeaderAcceptEncoding), page.Variants)
+	etag := page.VariantETag(encoding)
+
+	addVary(header, echo.HeaderAcceptEncoding)
+	addVary(header, "Accept-Language")
+	if consent.Personalized() {
+		// The consent cookie selects the rendering
+		addVary(header, echo.HeaderCookie)
+	}
+	header.Set("ETag", etag)
+
+	if etagMatches(request.Header.Get("If-None-Match"), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	body := page.Body
+	if encoding != "" {
+		header.Set(echo.HeaderContentEncoding, encoding)
+		body = page.Variants[encoding]
+	}
+
+	return c.Blob(http.StatusOK, page.ContentType, body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+var rateLimitDenialsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "nestattoboy",
+	Subsystem: "http",
+	Name:      "rate_limit_denials_total",
+	Help:      "Number of requests rejected by the rate limiter.",
+})
+
+// RateLimitMiddleware allows each client limit requests per second and answers
+// the rest with 429.
+func RateLimitMiddleware(limit rate.Limit) echo.MiddlewareFunc {
+	return echoMiddleware.RateLimiterWithConfig(echoMiddleware.RateLimiterConfig{
+		Store: echoMiddleware.NewRateLimiterMemoryStore(limit),
+		DenyHandler: func(c echo.Context, _ string, _ error) error {
+			rateLimitDenialsTotal.Inc()
+			return c.JSON(http.StatusTooManyRequests, map[string]string{
+				"error": "too many requests",
+			})
+		},
+	})
+}
+
+// Collectors returns the Prometheus collectors of the middlewares
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{rateLimitDenialsTotal}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/rand"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lexfrei/ne-stat-toboy/internal/logging"
+)
+
+// maxRequestIDLength bounds request IDs taken from clients
+const maxRequestIDLength = 64
+
+// RequestIDMiddleware tags every request with an ID, taken from the
+// X-Request-ID header when a proxy already set a valid one. The ID is sent
+// back in the response and carried by the request context for logging.
+func RequestIDMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			id := request.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(id) {
+				id = rand.Text()
+			}
+
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			c.SetRequest(request.WithContext(logging.WithRequestID(request.Context(), id)))
+
+			return next(c)
+		}
+	}
+}
+
+// validRequestID accepts short IDs of characters safe to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// addVary adds value to the Vary header unless it is already listed, e.g. by
+// the CSRF middleware
+func addVary(header http.Header, value string) {
+	for _, line := range header.Values(echo.HeaderVary) {
+		for _, v := range strings.Split(line, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return
+			}
+		}
+	}
+	header.Add(echo.HeaderVary, value)
+}
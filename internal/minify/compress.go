@@ -0,0 +1,174 @@
+package minify
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encoding is a content coding of a precompressed file
+type Encoding struct {
+	// Name is the Content-Encoding token
+	Name string
+	// Ext is the file extension of the precompressed sibling
+	Ext string
+}
+
+// Encodings lists the precompressed variants in order of preference
+var Encodings = []Encoding{
+	{Name: "br", Ext: ".br"},
+	{Name: "zstd", Ext: ".zst"},
+	{Name: "gzip", Ext: ".gz"},
+}
+
+// compressibleExts are the text formats worth precompressing
+var compressibleExts = map[string]bool{
+	".css":  true,
+	".js":   true,
+	".html": true,
+	".htm":  true,
+	".svg":  true,
+	".json": true,
+	".txt":  true,
+	".xml":  true,
+}
+
+// Compressible reports whether the named file is a text asset worth precompressing
+func Compressible(name string) bool {
+	return compressibleExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// Compress returns the content of a text asset in every encoding, keyed by
+// Content-Encoding token. Variants that are not smaller than the content are
+// left out, as is everything for files that are not Compressible.
+func Compress(name string, content []byte) (map[string][]byte, error) {
+	variants := make(map[string][]byte)
+	if !Compressible(name) || len(content) == 0 {
+		return variants, nil
+	}
+
+	for _, enc := range Encodings {
+		data, err := compress(enc.Name, content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress %s with %s: %w", name, enc.Name, err)
+		}
+		if len(data) < len(content) {
+			variants[enc.Name] = data
+		}
+	}
+
+	return variants, nil
+}
+
+func compress(encoding string, content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch encoding {
+	case "br":
+		w := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "zstd":
+		w, err := zstd.NewWriter(&buf, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Negotiate picks the encoding the client weights highest among those a
+// variant exists for; the order of Encodings breaks ties
+func Negotiate(acceptEncoding string, variants map[string][]byte) string {
+	names := make([]string, 0, len(Encodings))
+	for _, enc := range Encodings {
+		if _, exists := variants[enc.Name]; exists {
+			names = append(names, enc.Name)
+		}
+	}
+	return NegotiateNames(acceptEncoding, names)
+}
+
+// NegotiateNames picks the encoding of names, listed in order of server
+// preference, with the highest q-value in the Accept-Encoding header.
+// Encodings with q=0 are refused; "*" weights those not listed. It returns ""
+// when the client accepts none of them.
+func NegotiateNames(acceptEncoding string, names []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	wildcard := 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		token = strings.ToLower(strings.TrimSpace(token))
+		if token == "" {
+			continue
+		}
+		if token == "*" {
+			wildcard = qValue(params)
+			continue
+		}
+		weights[token] = qValue(params)
+	}
+
+	best, bestWeight := "", 0.0
+	for _, name := range names {
+		weight, listed := weights[name]
+		if !listed {
+			weight = wildcard
+		}
+		if weight > bestWeight {
+			best, bestWeight = name, weight
+		}
+	}
+	return best
+}
+
+// qValue returns the weight in the parameters of an Accept-Encoding entry;
+// malformed weights count as a refusal
+func qValue(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 || weight > 1 {
+			return 0
+		}
+		return weight
+	}
+	return 1
+}
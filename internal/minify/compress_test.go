@@ -0,0 +1,41 @@
+package minify
+
+import "testing"
+
+func TestNegotiateNames(t *testing.T) {
+	names := []string{"br", "gzip"}
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"gzip, br", "br"},
+		{"br;q=0.1, gzip;q=1", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"br;q=0", ""},
+		{"gzip;q=0.5, br;q=0.5", "br"},
+		{"identity", ""},
+		{"*", "br"},
+		{"*;q=0.2, gzip;q=0.8", "gzip"},
+		{"br;q=0, *", "gzip"},
+		{"BR;Q=0.9, gzip;q=0.3", "br"},
+		{"br;q=abc, gzip", "gzip"},
+		{"br;q=2, gzip", "gzip"},
+		{"gzip ; q=0.7 , br ; q=0.6", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := NegotiateNames(tt.acceptEncoding, names); got != tt.want {
+			t.Errorf("NegotiateNames(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiateSkipsMissingVariants(t *testing.T) {
+	variants := map[string][]byte{"gzip": nil, "zstd": nil}
+	if got := Negotiate("br, zstd;q=0.5, gzip;q=0.5", variants); got != "zstd" {
+		t.Errorf("Negotiate = %q, want zstd", got)
+	}
+	if got := Negotiate("br", variants); got != "" {
+		t.Errorf("Negotiate = %q, want none", got)
+	}
+}
@@ -3,7 +3,7 @@ package minify
 
 import (
 	"fmt"
-	"log/slog"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,79 +15,186 @@ import (
 	"github.com/tdewolff/minify/v2/svg"
 )
 
-// MinifyStaticFiles minifies CSS, JS, HTML and SVG files in the specified directory.
-func MinifyStaticFiles(staticDir string) error {
+// mediaTypes maps file extensions to the media types that can be minified
+var mediaTypes = map[string]string{
+	".css":  "text/css",
+	".js":   "application/javascript",
+	".html": "text/html",
+	".htm":  "text/html",
+	".svg":  "image/svg+xml",
+}
+
+func newMinifier() *minify.M {
 	m := minify.New()
 	m.AddFunc("text/css", css.Minify)
 	m.AddFunc("application/javascript", js.Minify)
 	m.AddFunc("text/html", html.Minify)
 	m.AddFunc("image/svg+xml", svg.Minify)
+	return m
+}
 
-	// Create a standalone command for minification
-	return filepath.Walk(staticDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+var defaultMinifier = newMinifier()
+
+// Bytes minifies the content of the named file by its extension. Files of
+// other types, and files that would not get smaller, are returned unchanged.
+func Bytes(name string, content []byte) ([]byte, error) {
+	mediaType, ok := mediaTypes[strings.ToLower(filepath.Ext(name))]
+	if !ok || len(content) == 0 {
+		return content, nil
+	}
+
+	minified, err := defaultMinifier.Bytes(mediaType, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to minify %s: %w", name, err)
+	}
+
+	if len(minified) >= len(content) {
+		return content, nil
+	}
+
+	return minified, nil
+}
+
+// Result describes the minification of a single file
+type Result struct {
+	Path         string
+	OriginalSize int
+	MinifiedSize int
+}
+
+// Changed reports whether minification changes the file
+func (r Result) Changed() bool {
+	return r.MinifiedSize != r.OriginalSize
+}
+
+// WriteOption is a functional option for WriteDir
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	precompress bool
+}
+
+// WithPrecompression also writes .br, .zst and .gz siblings of text assets
+func WithPrecompression() WriteOption {
+	return func(o *writeOptions) {
+		o.precompress = true
+	}
+}
+
+// WriteDir minifies CSS, JS, HTML and SVG files from srcDir into outDir and
+// copies all other files unchanged. Files in srcDir are never modified.
+func WriteDir(srcDir, outDir string, opts ...WriteOption) ([]Result, error) {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	inside, err := within(srcDir, outDir)
+	if err != nil {
+		return nil, err
+	}
+	if inside {
+		return nil, fmt.Errorf("output directory %s must be outside the source directory", outDir)
+	}
+
+	return walk(srcDir, func(rel string, info fs.FileInfo, minified []byte) error {
+		dest := filepath.Join(outDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", dest, err)
+		}
+		if err := os.WriteFile(dest, minified, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write minified file %s: %w", dest, err)
 		}
 
-		if info.IsDir() {
+		if !o.precompress {
 			return nil
 		}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		var mediaType string
-		switch ext {
-		case ".css":
-			mediaType = "text/css"
-		case ".js":
-			mediaType = "application/javascript"
-		case ".html", ".htm":
-			mediaType = "text/html"
-		case ".svg":
-			mediaType = "image/svg+xml"
-		default:
-			return nil
+		variants, err := Compress(rel, minified)
+		if err != nil {
+			return err
+		}
+		for _, enc := range Encodings {
+			data, ok := variants[enc.Name]
+			if !ok {
+				continue
+			}
+			if err := os.WriteFile(dest+enc.Ext, data, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to write compressed file %s: %w", dest+enc.Ext, err)
+			}
+		}
+		return nil
+	})
+}
+
+// CheckDir minifies the files in dir without writing anything and returns
+// the result for every file that can be minified
+func CheckDir(dir string) ([]Result, error) {
+	return walk(dir, nil)
+}
+
+// walk minifies every file below dir and passes the output to write, if set
+func walk(dir string, write func(rel string, info fs.FileInfo, minified []byte) error) ([]Result, error) {
+	var results []Result
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
 		}
 
-		// Read file
 		content, err := os.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to read file %s: %w", path, err)
 		}
 
-		if len(content) == 0 {
-			return nil
+		minified, err := Bytes(path, content)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := mediaTypes[strings.ToLower(filepath.Ext(path))]; ok {
+			results = append(results, Result{
+				Path:         path,
+				OriginalSize: len(content),
+				MinifiedSize: len(minified),
+			})
 		}
 
-		// Check if minifier exists for this type
-		_, _, minifierFunc := m.Match(mediaType)
-		if minifierFunc == nil {
-			slog.Debug("No minifier for file type", "path", path, "mediaType", mediaType)
+		if write == nil {
 			return nil
 		}
 
-		// Minify content
-		minified, err := m.Bytes(mediaType, content)
+		rel, err := filepath.Rel(dir, path)
 		if err != nil {
-			return fmt.Errorf("failed to minify %s: %w", path, err)
+			return err
 		}
-
-		if len(minified) >= len(content) {
-			slog.Debug("No size reduction after minification", "file", path)
-			return nil
+		info, err := d.Info()
+		if err != nil {
+			return err
 		}
+		return write(rel, info, minified)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// Write minified content
-		if err := os.WriteFile(path, minified, info.Mode()); err != nil {
-			return fmt.Errorf("failed to write minified file %s: %w", path, err)
-		}
+	return results, nil
+}
 
-		slog.Info("Minified file", 
-			"file", path,
-			"originalSize", len(content),
-			"minifiedSize", len(minified),
-			"reduction", fmt.Sprintf("%.2f%%", (1-float64(len(minified))/float64(len(content)))*100),
-		)
+// within reports whether path is dir itself or lies below it
+func within(dir, path string) (bool, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
 
-		return nil
-	})
-}
\ No newline at end of file
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false, err
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))), nil
+}
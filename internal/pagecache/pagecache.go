@@ -0,0 +1,113 @@
+// Package pagecache keeps rendered pages in memory, ready to serve in every encoding.
+package pagecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/minify"
+)
+
+// Page is a rendered page with its precompressed variants
+type Page struct {
+	// ContentType is sent with every variant
+	ContentType string
+	// Body is the minified, uncompressed page
+	Body []byte
+	// ETag is the strong validator of Body
+	ETag string
+	// Variants holds Body compressed, keyed by Content-Encoding token
+	Variants map[string][]byte
+}
+
+// VariantETag returns the strong validator of the given encoding of the page
+func (p *Page) VariantETag(encoding string) string {
+	if encoding == "" {
+		return p.ETag
+	}
+	return p.ETag[:len(p.ETag)-1] + "-" + encoding + `"`
+}
+
+// Cache maps a route and locale to its rendered page. Entries are keyed by
+// the content version, so Invalidate drops them all at once.
+type Cache struct {
+	version atomic.Uint64
+	mu      sync.RWMutex
+	pages   map[string]*Page
+}
+
+// New creates an empty cache
+func New() *Cache {
+	return &Cache{pages: make(map[string]*Page)}
+}
+
+// Version returns the current content version
+func (c *Cache) Version() uint64 {
+	return c.version.Load()
+}
+
+// Key builds the cache key of a route at the current content version; variants
+// such as the locale tell apart different renderings of the same route
+func (c *Cache) Key(path string, variants ...string) string {
+	parts := append([]string{path}, variants...)
+	parts = append(parts, strconv.FormatUint(c.Version(), 10))
+	return strings.Join(parts, "|")
+}
+
+// Get returns the page stored under key
+func (c *Cache) Get(key string) (*Page, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	page, ok := c.pages[key]
+	return page, ok
+}
+
+// Put minifies and compresses an HTML page and stores it under key
+func (c *Cache) Put(key, contentType string, body []byte) (*Page, error) {
+	minified, err := minify.Bytes("page.html", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to minify page %s: %w", key, err)
+	}
+
+	variants, err := minify.Compress("page.html", minified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress page %s: %w", key, err)
+	}
+
+	sum := sha256.Sum256(minified)
+	page := &Page{
+		ContentType: contentType,
+		Body:        minified,
+		ETag:        `"` + hex.EncodeToString(sum[:8]) + `"`,
+		Variants:    variants,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pages[key] = page
+	return page, nil
+}
+
+// Invalidate moves to a new content version and drops every stored page
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version.Add(1)
+	c.pages = make(map[string]*Page)
+}
+
+// Len returns the number of stored pages
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.pages)
+}
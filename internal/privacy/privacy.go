@@ -0,0 +1,92 @@
+// Package privacy implements personal data retention, export and erasure (152-FZ/GDPR).
+package privacy
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+)
+
+// Export is everything we store about a person, keyed by email address
+type Export struct {
+	Email       string               `json:"email"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	Submissions []storage.Submission `json:"submissions"`
+}
+
+// ExportByEmail collects all personal data tied to the email address
+func ExportByEmail(store *storage.Store, email string) Export {
+	submissions := store.FindByEmail(email)
+	if submissions == nil {
+		submissions = []storage.Submission{}
+	}
+
+	return Export{
+		Email:       email,
+		GeneratedAt: time.Now().UTC(),
+		Submissions: submissions,
+	}
+}
+
+// EraseByEmail deletes all personal data tied to the email address
+func EraseByEmail(store *storage.Store, email string) (int, error) {
+	deleted, err := store.DeleteByEmail(email)
+	if err != nil {
+		return 0, err
+	}
+
+	slog.Info("Personal data erased", "email", RedactEmail(email), "submissions", deleted)
+	return deleted, nil
+}
+
+// Purge deletes submissions older than the retention period
+func Purge(store *storage.Store, retention time.Duration, now time.Time) (int, error) {
+	deleted, err := store.DeleteBefore(now.Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		slog.Info("Expired submissions purged", "submissions", deleted, "retention", retention)
+	}
+	return deleted, nil
+}
+
+// RunRetention purges expired submissions every interval until ctx is
+// cancelled. With a non-positive interval they are only purged once, at start.
+func RunRetention(ctx context.Context, store *storage.Store, retention, interval time.Duration) {
+	if interval <= 0 {
+		if _, err := Purge(store, retention, time.Now()); err != nil {
+			slog.Error("Failed to purge expired submissions", "error", err)
+		}
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := Purge(store, retention, time.Now()); err != nil {
+			slog.Error("Failed to purge expired submissions", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RedactEmail masks the local part of an address for logging: "me@masha.film" becomes "m***@masha.film"
+func RedactEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+
+	return string([]rune(local)[0]) + "***@" + domain
+}
@@ -0,0 +1,115 @@
+package privacy_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/privacy"
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+)
+
+var now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// seed creates a store with submissions of two people, one of them expired
+func seed(t *testing.T) *storage.Store {
+	t.Helper()
+
+	store, err := storage.Open(filepath.Join(t.TempDir(), "submissions.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, sub := range []storage.Submission{
+		{Email: "masha@example.com", Message: "old", CreatedAt: now.AddDate(-2, 0, 0)},
+		{Email: "Masha@Example.com", Message: "recent", CreatedAt: now.AddDate(0, -1, 0)},
+		{Email: "other@example.com", Message: "other", CreatedAt: now.AddDate(0, 0, -1)},
+	} {
+		if _, err := store.Create(sub); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	return store
+}
+
+func TestExportByEmail(t *testing.T) {
+	store := seed(t)
+
+	export := privacy.ExportByEmail(store, "MASHA@example.com")
+	if len(export.Submissions) != 2 {
+		t.Fatalf("exported %d submissions, want 2", len(export.Submissions))
+	}
+	if export.Submissions[0].Message != "old" || export.Submissions[1].Message != "recent" {
+		t.Errorf("exported submissions out of order: %+v", export.Submissions)
+	}
+
+	if empty := privacy.ExportByEmail(store, "nobody@example.com"); empty.Submissions == nil || len(empty.Submissions) != 0 {
+		t.Errorf("export of an unknown address = %+v, want an empty list", empty.Submissions)
+	}
+}
+
+func TestEraseByEmail(t *testing.T) {
+	store := seed(t)
+
+	deleted, err := privacy.EraseByEmail(store, "masha@example.com")
+	if err != nil || deleted != 2 {
+		t.Fatalf("EraseByEmail = %d, %v; want 2", deleted, err)
+	}
+	if left := store.FindByEmail("masha@example.com"); len(left) != 0 {
+		t.Errorf("submissions left after erasure: %+v", left)
+	}
+	if left := store.List(); len(left) != 1 || left[0].Email != "other@example.com" {
+		t.Errorf("other people's submissions = %+v", left)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	store := seed(t)
+
+	deleted, err := privacy.Purge(store, 365*24*time.Hour, now)
+	if err != nil || deleted != 1 {
+		t.Fatalf("Purge = %d, %v; want 1", deleted, err)
+	}
+	for _, sub := range store.List() {
+		if sub.Message == "old" {
+			t.Error("expired submission kept")
+		}
+	}
+
+	if deleted, err := privacy.Purge(store, 365*24*time.Hour, now); err != nil || deleted != 0 {
+		t.Errorf("second Purge = %d, %v; want nothing left to purge", deleted, err)
+	}
+}
+
+func TestRunRetentionWithoutIntervalPurgesOnce(t *testing.T) {
+	store := seed(t)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		privacy.RunRetention(context.Background(), store, time.Since(now.AddDate(-1, 0, 0)), 0)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunRetention kept running without an interval")
+	}
+
+	if n := len(store.List()); n != 2 {
+		t.Errorf("%d submissions left, want the expired one purged", n)
+	}
+}
+
+func TestRedactEmail(t *testing.T) {
+	tests := map[string]string{
+		"me@masha.film": "m***@masha.film",
+		"маша@почта.рф": "м***@почта.рф",
+		"@masha.film":   "***",
+		"not-an-email":  "***",
+	}
+	for email, want := range tests {
+		if got := privacy.RedactEmail(email); got != want {
+			t.Errorf("RedactEmail(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package realip
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// builtinCloudflare is the list of Cloudflare ranges used until it is
+// refreshed from the API
+//
+//go:embed cloudflare.txt
+var builtinCloudflare string
+
+// parseRanges parses one CIDR per line, skipping blank lines and comments
+func parseRanges(list string) ([]netip.Prefix, error) {
+	var ranges []netip.Prefix
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(line)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, prefix.Masked())
+	}
+	return ranges, scanner.Err()
+}
+
+type cloudflareIPsResponse struct {
+	Success bool `json:"success"`
+	Result  struct {
+		IPv4CIDRs []string `json:"ipv4_cidrs"`
+		IPv6CIDRs []string `json:"ipv6_cidrs"`
+	} `json:"result"`
+}
+
+// fetchCloudflare gets the edge ranges from the public /ips endpoint of the
+// Cloudflare API
+func fetchCloudflare(ctx context.Context, httpClient *http.Client, baseURL string) ([]netip.Prefix, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/ips", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IP ranges request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare IP ranges request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result cloudflareIPsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.Success || resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cloudflare API error: %s", resp.Status)
+	}
+
+	cidrs := append(result.Result.IPv4CIDRs, result.Result.IPv6CIDRs...)
+	if len(cidrs) == 0 {
+		// An empty list would stop trusting the edge altogether
+		return nil, errors.New("cloudflare API returned no IP ranges")
+	}
+
+	ranges, err := parseRanges(strings.Join(cidrs, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Cloudflare IP range: %w", err)
+	}
+	return ranges, nil
+}
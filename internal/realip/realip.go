@@ -0,0 +1,218 @@
+// Package realip determines the client IP address of requests that reach the
+// server through trusted proxies, such as the Cloudflare edge.
+package realip
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// HeaderCFConnectingIP carries the client address set by the Cloudflare edge
+const HeaderCFConnectingIP = "CF-Connecting-IP"
+
+// Extractor takes the client IP from forwarding headers only when they were
+// set by trusted proxies, so clients cannot choose their own address
+type Extractor struct {
+	trusted    []netip.Prefix
+	cloudflare atomic.Pointer[[]netip.Prefix]
+
+	useCloudflare bool
+	apiURL        string
+	httpClient    *http.Client
+}
+
+// Option is a functional option for configuring the extractor
+type Option func(*Extractor)
+
+// WithCloudflare trusts the Cloudflare edge and takes the client IP from the
+// CF-Connecting-IP header of requests it forwards
+func WithCloudflare() Option {
+	return func(e *Extractor) {
+		e.useCloudflare = true
+	}
+}
+
+// WithCloudflareAPI sets the API that Cloudflare's ranges are refreshed from,
+// e.g. a local fake
+func WithCloudflareAPI(baseURL string) Option {
+	return func(e *Extractor) {
+		if baseURL != "" {
+			e.apiURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to refresh Cloudflare's ranges
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(e *Extractor) {
+		e.httpClient = httpClient
+	}
+}
+
+// New creates an extractor trusting proxies in the given CIDRs; single
+// addresses are accepted as well
+func New(trustedCIDRs []string, opts ...Option) (*Extractor, error) {
+	e := &Extractor{
+		apiURL:     "https://api.cloudflare.com/client/v4",
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	for _, cidr := range trustedCIDRs {
+		prefix, err := parsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		e.trusted = append(e.trusted, prefix)
+	}
+
+	if e.useCloudflare {
+		ranges, err := parseRanges(builtinCloudflare)
+		if err != nil {
+			return nil, fmt.Errorf("invalid built-in Cloudflare ranges: %w", err)
+		}
+		e.cloudflare.Store(&ranges)
+	}
+
+	return e, nil
+}
+
+// Extract returns the client IP of r, for echo's IPExtractor. Starting at the
+// connection peer, the X-Forwarded-For chain is followed from the right as
+// long as each hop is a trusted proxy; the first untrusted address is the
+// client. When a hop is the Cloudflare edge, its CF-Connecting-IP is used.
+func (e *Extractor) Extract(r *http.Request) string {
+	hop, ok := parseHost(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+
+	forwarded := forwardedFor(r.Header)
+	for {
+		if e.isCloudflare(hop) {
+			if client, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get(HeaderCFConnectingIP))); err == nil {
+				return client.Unmap().String()
+			}
+		}
+		if !e.isTrusted(hop) || len(forwarded) == 0 {
+			return hop.String()
+		}
+
+		next, err := netip.ParseAddr(forwarded[len(forwarded)-1])
+		if err != nil {
+			// A malformed entry was not written by a trusted proxy
+			return hop.String()
+		}
+		hop = next.Unmap()
+		forwarded = forwarded[:len(forwarded)-1]
+	}
+}
+
+// Run refreshes Cloudflare's ranges every interval until ctx is done,
+// keeping the previous list when the API cannot be reached
+func (e *Extractor) Run(ctx context.Context, interval time.Duration) {
+	if !e.useCloudflare || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.RefreshCloudflare(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to refresh Cloudflare IP ranges", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshCloudflare replaces Cloudflare's ranges with the list published by
+// its API
+func (e *Extractor) RefreshCloudflare(ctx context.Context) error {
+	ranges, err := fetchCloudflare(ctx, e.httpClient, e.apiURL)
+	if err != nil {
+		return err
+	}
+
+	if old := e.cloudflare.Load(); old == nil || !slices.Equal(*old, ranges) {
+		slog.InfoContext(ctx, "Cloudflare IP ranges updated", "ranges", len(ranges))
+	}
+	e.cloudflare.Store(&ranges)
+	return nil
+}
+
+func (e *Extractor) isTrusted(ip netip.Addr) bool {
+	return contains(e.trusted, ip) || e.isCloudflare(ip)
+}
+
+func (e *Extractor) isCloudflare(ip netip.Addr) bool {
+	ranges := e.cloudflare.Load()
+	return ranges != nil && contains(*ranges, ip)
+}
+
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor returns the addresses of all X-Forwarded-For headers in order
+func forwardedFor(h http.Header) []string {
+	var addrs []string
+	for _, v := range h.Values("X-Forwarded-For") {
+		for _, a := range strings.Split(v, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				addrs = append(addrs, a)
+			}
+		}
+	}
+	return addrs
+}
+
+// parseHost parses the address of a host:port pair
+func parseHost(hostport string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+// parsePrefix parses a CIDR or a single address
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		return netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+	}
+	return prefix.Masked(), nil
+}
@@ -0,0 +1,204 @@
+package realip
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// cloudflareEdge is an address in the built-in Cloudflare ranges
+const cloudflareEdge = "173.245.48.10"
+
+func TestExtract(t *testing.T) {
+	e, err := New([]string{"10.0.0.0/8", "192.0.2.1"}, WithCloudflare())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		cfIP       string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "", "203.0.113.7"},
+		{"spoofed X-Forwarded-For from an untrusted peer", "203.0.113.7:5000", []string{"1.2.3.4"}, "", "203.0.113.7"},
+		{"spoofed CF-Connecting-IP from an untrusted peer", "203.0.113.7:5000", nil, "1.2.3.4", "203.0.113.7"},
+		{"spoofed CF-Connecting-IP from a trusted proxy", "10.0.0.2:5000", []string{"198.51.100.9"}, "1.2.3.4", "198.51.100.9"},
+		{"trusted proxy", "10.0.0.2:5000", []string{"198.51.100.9"}, "", "198.51.100.9"},
+		{"trusted single address", "192.0.2.1:5000", []string{"198.51.100.9"}, "", "198.51.100.9"},
+		{"trusted chain", "10.0.0.2:5000", []string{"1.2.3.4, 198.51.100.9, 10.0.0.3"}, "", "198.51.100.9"},
+		{"chain in separate headers", "10.0.0.2:5000", []string{"198.51.100.9", "10.0.0.3"}, "", "198.51.100.9"},
+		{"trusted proxy without header", "10.0.0.2:5000", nil, "", "10.0.0.2"},
+		{"malformed entry", "10.0.0.2:5000", []string{"198.51.100.9, not-an-ip"}, "", "10.0.0.2"},
+		{"malformed entry behind a trusted hop", "10.0.0.2:5000", []string{"garbage, 10.0.0.3"}, "", "10.0.0.3"},
+		{"Cloudflare edge", cloudflareEdge + ":443", nil, "198.51.100.9", "198.51.100.9"},
+		{"Cloudflare edge behind a trusted proxy", "10.0.0.2:5000", []string{cloudflareEdge}, "198.51.100.9", "198.51.100.9"},
+		{"Cloudflare edge with an invalid header", cloudflareEdge + ":443", []string{"198.51.100.9"}, "bogus", "198.51.100.9"},
+		{"IPv4-mapped peer", "[::ffff:10.0.0.2]:5000", []string{"198.51.100.9"}, "", "198.51.100.9"},
+		{"IPv4-mapped entry", "10.0.0.2:5000", []string{"::ffff:198.51.100.9"}, "", "198.51.100.9"},
+		{"IPv4-mapped CF-Connecting-IP", cloudflareEdge + ":443", nil, "::ffff:198.51.100.9", "198.51.100.9"},
+		{"IPv6 client", "10.0.0.2:5000", []string{"2001:db8::1"}, "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.cfIP != "" {
+				req.Header.Set(HeaderCFConnectingIP, tt.cfIP)
+			}
+
+			if got := e.Extract(req); got != tt.want {
+				t.Errorf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractWithoutCloudflareIgnoresTheEdge(t *testing.T) {
+	e, err := New(nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = cloudflareEdge + ":443"
+	req.Header.Set(HeaderCFConnectingIP, "198.51.100.9")
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+
+	if got := e.Extract(req); got != cloudflareEdge {
+		t.Errorf("Extract = %q, want the peer %s", got, cloudflareEdge)
+	}
+}
+
+func TestNewRejectsInvalidProxies(t *testing.T) {
+	if _, err := New([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("New accepted an invalid CIDR")
+	}
+	if _, err := New([]string{"proxy.local"}); err == nil {
+		t.Error("New accepted a host name")
+	}
+}
+
+func TestFetchCloudflare(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    int
+		wantErr string
+	}{
+		{
+			name:   "ranges",
+			status: http.StatusOK,
+			body:   `{"success":true,"result":{"ipv4_cidrs":["173.245.48.0/20","103.21.244.0/22"],"ipv6_cidrs":["2400:cb00::/32"]}}`,
+			want:   3,
+		},
+		{
+			name:    "empty list",
+			status:  http.StatusOK,
+			body:    `{"success":true,"result":{"ipv4_cidrs":[],"ipv6_cidrs":[]}}`,
+			wantErr: "no IP ranges",
+		},
+		{
+			name:    "unsuccessful response",
+			status:  http.StatusOK,
+			body:    `{"success":false,"errors":[{"code":10000,"message":"error"}]}`,
+			wantErr: "cloudflare API error",
+		},
+		{
+			name:    "server error",
+			status:  http.StatusInternalServerError,
+			body:    `{"success":true,"result":{"ipv4_cidrs":["173.245.48.0/20"]}}`,
+			wantErr: "500",
+		},
+		{
+			name:    "not JSON",
+			status:  http.StatusOK,
+			body:    `<html>maintenance</html>`,
+			wantErr: "cloudflare API error",
+		},
+		{
+			name:    "invalid range",
+			status:  http.StatusOK,
+			body:    `{"success":true,"result":{"ipv4_cidrs":["173.245.48.0/40"]}}`,
+			wantErr: "invalid Cloudflare IP range",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/client/v4/ips" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			ranges, err := fetchCloudflare(context.Background(), server.Client(), server.URL+"/client/v4")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchCloudflare error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchCloudflare: %v", err)
+			}
+			if len(ranges) != tt.want {
+				t.Errorf("got %d ranges, want %d: %v", len(ranges), tt.want, ranges)
+			}
+		})
+	}
+}
+
+func TestRefreshCloudflareKeepsRangesOnError(t *testing.T) {
+	ranges := `{"success":true,"result":{"ipv4_cidrs":["198.18.0.0/15"],"ipv6_cidrs":[]}}`
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(ranges))
+	}))
+	defer server.Close()
+
+	e, err := New(nil, WithCloudflare(), WithCloudflareAPI(server.URL), WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := e.RefreshCloudflare(context.Background()); err != nil {
+		t.Fatalf("RefreshCloudflare: %v", err)
+	}
+
+	extract := func(peer string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = peer + ":443"
+		req.Header.Set(HeaderCFConnectingIP, "198.51.100.9")
+		return e.Extract(req)
+	}
+	if got := extract("198.18.0.1"); got != "198.51.100.9" {
+		t.Errorf("refreshed edge not trusted: Extract = %q", got)
+	}
+	if got := extract(cloudflareEdge); got != cloudflareEdge {
+		t.Errorf("built-in range still trusted after refresh: Extract = %q", got)
+	}
+
+	failing.Store(true)
+	if err := e.RefreshCloudflare(context.Background()); err == nil {
+		t.Fatal("RefreshCloudflare succeeded against a failing API")
+	}
+	if got := extract("198.18.0.1"); got != "198.51.100.9" {
+		t.Errorf("ranges dropped after a failed refresh: Extract = %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// defaultChunkSize matches the clamd StreamMaxLength-friendly chunk size used by clamdscan
+const defaultChunkSize = 64 * 1024
+
+// Clamd is a Scanner speaking the clamd INSTREAM protocol over TCP or a Unix socket
+type Clamd struct {
+	network   string
+	address   string
+	timeout   time.Duration
+	chunkSize int
+}
+
+// NewClamd creates a clamd client. The address may be "tcp://host:port",
+// "unix:///path/to/clamd.sock" or a bare "host:port".
+func NewClamd(address string, timeout time.Duration) (*Clamd, error) {
+	network, addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &Clamd{
+		network:   network,
+		address:   addr,
+		timeout:   timeout,
+		chunkSize: defaultChunkSize,
+	}, nil
+}
+
+func parseAddress(address string) (string, string, error) {
+	switch {
+	case address == "":
+		return "", "", fmt.Errorf("empty clamd address")
+	case strings.HasPrefix(address, "unix://"):
+		return "unix", strings.TrimPrefix(address, "unix://"), nil
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp", strings.TrimPrefix(address, "tcp://"), nil
+	case strings.HasPrefix(address, "/"):
+		return "unix", address, nil
+	default:
+		return "tcp", address, nil
+	}
+}
+
+// Ping checks that clamd is reachable and responding
+func (c *Clamd) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, func(w io.Writer) error {
+		_, err := io.WriteString(w, "zPING\x00")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if reply != "PONG" {
+		return fmt.Errorf("unexpected clamd ping reply: %q", reply)
+	}
+
+	return nil
+}
+
+// Scan streams the data to clamd and parses the verdict
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	reply, err := c.command(ctx, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+			return err
+		}
+
+		buf := make([]byte, c.chunkSize)
+		size := make([]byte, 4)
+		for {
+			n, readErr := r.Read(buf)
+			if n > 0 {
+				binary.BigEndian.PutUint32(size, uint32(n))
+				if _, err := w.Write(size); err != nil {
+					return err
+				}
+				if _, err := w.Write(buf[:n]); err != nil {
+					return err
+				}
+			}
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				return fmt.Errorf("failed to read upload: %w", readErr)
+			}
+		}
+
+		// A zero-length chunk terminates the stream
+		binary.BigEndian.PutUint32(size, 0)
+		_, err := w.Write(size)
+		return err
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return parseReply(reply)
+}
+
+// command opens a connection, writes the request and reads a single NUL-terminated reply
+func (c *Clamd) command(ctx context.Context, write func(io.Writer) error) (string, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return "", fmt.Errorf("failed to set clamd deadline: %w", err)
+	}
+
+	w := bufio.NewWriterSize(conn, c.chunkSize+4)
+	if err := write(w); err != nil {
+		return "", fmt.Errorf("failed to send data to clamd: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("failed to send data to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && !(err == io.EOF && len(reply) > 0) {
+		return "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
+
+// parseReply interprets replies such as "stream: OK" or "stream: Eicar-Signature FOUND"
+func parseReply(reply string) (Result, error) {
+	status := strings.TrimSpace(reply)
+	if i := strings.Index(status, ": "); i >= 0 {
+		status = status[i+2:]
+	}
+
+	switch {
+	case status == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(status, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(status, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd error: %s", reply)
+	}
+}
@@ -0,0 +1,156 @@
+// Package clamdtest provides a fake clamd server for tests.
+package clamdtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// EICAR is the standard antivirus test string; the fake server reports it as infected.
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Server is a minimal clamd implementation supporting PING and INSTREAM.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu         sync.Mutex
+	signatures map[string]string
+	failing    bool
+	scans      int
+}
+
+// NewServer starts a fake clamd on a random local TCP port.
+// It reports EICAR as "Eicar-Test-Signature" by default.
+func NewServer() (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: l,
+		signatures: map[string]string{
+			EICAR: "Eicar-Test-Signature",
+		},
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Address returns the address to pass to scanner.NewClamd
+func (s *Server) Address() string {
+	return "tcp://" + s.listener.Addr().String()
+}
+
+// AddSignature makes the server report streams containing pattern as infected
+func (s *Server) AddSignature(pattern, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signatures[pattern] = name
+}
+
+// SetFailing makes the server answer every scan with an error reply
+func (s *Server) SetFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+// Scans returns the number of INSTREAM requests served
+func (s *Server) Scans() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scans
+}
+
+// Close stops the server and waits for open connections to finish
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	switch cmd {
+	case "zPING\x00":
+		_, _ = io.WriteString(conn, "PONG\x00")
+	case "zINSTREAM\x00":
+		data, err := readStream(r)
+		if err != nil {
+			_, _ = io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+			return
+		}
+		_, _ = io.WriteString(conn, s.verdict(data)+"\x00")
+	default:
+		_, _ = io.WriteString(conn, "UNKNOWN COMMAND\x00")
+	}
+}
+
+func readStream(r io.Reader) ([]byte, error) {
+	var data bytes.Buffer
+	size := make([]byte, 4)
+
+	for {
+		if _, err := io.ReadFull(r, size); err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(size)
+		if n == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *Server) verdict(data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scans++
+
+	if s.failing {
+		return "stream: Can't allocate memory ERROR"
+	}
+
+	for pattern, name := range s.signatures {
+		if bytes.Contains(data, []byte(pattern)) {
+			return "stream: " + name + " FOUND"
+		}
+	}
+
+	return "stream: OK"
+}
@@ -0,0 +1,32 @@
+package scanner
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	scansTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "nestattoboy",
+		Subsystem: "scanner",
+		Name:      "scans_total",
+		Help:      "Number of upload malware scans by result (clean, infected, error).",
+	}, []string{"result"})
+
+	scanDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "nestattoboy",
+		Subsystem: "scanner",
+		Name:      "scan_duration_seconds",
+		Help:      "Time spent scanning uploads.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	quarantinedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "nestattoboy",
+		Subsystem: "scanner",
+		Name:      "quarantined_total",
+		Help:      "Number of infected uploads moved to quarantine.",
+	})
+)
+
+// Collectors returns the Prometheus collectors of the scanner
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{scansTotal, scanDuration, quarantinedTotal}
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Quarantine keeps infected files out of the upload directory for later inspection
+type Quarantine struct {
+	dir string
+}
+
+// quarantineRecord is written next to every quarantined file
+type quarantineRecord struct {
+	OriginalName string    `json:"original_name"`
+	Signature    string    `json:"signature"`
+	Size         int64     `json:"size"`
+	Time         time.Time `json:"time"`
+}
+
+// NewQuarantine creates a quarantine storing files in dir
+func NewQuarantine(dir string) *Quarantine {
+	return &Quarantine{dir: dir}
+}
+
+// Store copies the infected data into the quarantine and returns its path.
+// Files are written without execute or group/other permissions.
+func (q *Quarantine) Store(name, signature string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(q.dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate quarantine id: %w", err)
+	}
+	base := time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(id)
+	path := filepath.Join(q.dir, base+".quarantine")
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create quarantine file: %w", err)
+	}
+	size, copyErr := io.Copy(f, r)
+	if err := f.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if copyErr != nil {
+		return "", fmt.Errorf("failed to write quarantine file: %w", copyErr)
+	}
+
+	meta, err := json.MarshalIndent(quarantineRecord{
+		OriginalName: filepath.Base(name),
+		Signature:    signature,
+		Size:         size,
+		Time:         time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal quarantine record: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(q.dir, base+".json"), meta, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write quarantine record: %w", err)
+	}
+
+	return path, nil
+}
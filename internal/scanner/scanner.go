@@ -0,0 +1,155 @@
+// Package scanner provides malware scanning for uploaded files.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// ErrUnavailable is returned when a file could not be scanned and the policy is fail-closed.
+var ErrUnavailable = errors.New("malware scanner unavailable")
+
+// Result describes the outcome of a single scan
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// Scanner scans a stream of data for malware
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// Policy decides what happens to a file when the scanner fails
+type Policy int
+
+const (
+	// FailClosed rejects files that could not be scanned
+	FailClosed Policy = iota
+	// FailOpen accepts files that could not be scanned
+	FailOpen
+)
+
+// ParsePolicy converts a config value ("open" or "closed") to a Policy
+func ParsePolicy(s string) (Policy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "closed", "fail-closed":
+		return FailClosed, nil
+	case "open", "fail-open":
+		return FailOpen, nil
+	default:
+		return FailClosed, fmt.Errorf("unknown scanner policy %q", s)
+	}
+}
+
+// String returns the config representation of the policy
+func (p Policy) String() string {
+	if p == FailOpen {
+		return "open"
+	}
+	return "closed"
+}
+
+// InfectedError is returned when a scanned file contains malware
+type InfectedError struct {
+	Signature string
+}
+
+func (e *InfectedError) Error() string {
+	return "malware detected: " + e.Signature
+}
+
+// Guard combines a Scanner with a failure policy and quarantine storage.
+type Guard struct {
+	scanner    Scanner
+	policy     Policy
+	quarantine *Quarantine
+}
+
+// GuardOption is a functional option for configuring the guard
+type GuardOption func(*Guard)
+
+// WithPolicy sets the fail-open/fail-closed policy
+func WithPolicy(p Policy) GuardOption {
+	return func(g *Guard) {
+		g.policy = p
+	}
+}
+
+// WithQuarantine stores infected files in the given quarantine
+func WithQuarantine(q *Quarantine) GuardOption {
+	return func(g *Guard) {
+		g.quarantine = q
+	}
+}
+
+// NewGuard creates a new Guard. A nil scanner is treated as unavailable,
+// so uploads are then accepted or rejected purely by policy.
+func NewGuard(s Scanner, opts ...GuardOption) *Guard {
+	g := &Guard{
+		scanner: s,
+		policy:  FailClosed,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Check scans the file and returns nil if it may be accepted.
+// Infected files are quarantined and reported as *InfectedError.
+func (g *Guard) Check(ctx context.Context, name string, file io.ReadSeeker) error {
+	start := time.Now()
+	result, err := g.scan(ctx, file)
+	scanDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		scansTotal.WithLabelValues("error").Inc()
+		if g.policy == FailOpen {
+			slog.Warn("Malware scan failed, accepting file by fail-open policy", "file", name, "error", err)
+			return nil
+		}
+		slog.Error("Malware scan failed, rejecting file by fail-closed policy", "file", name, "error", err)
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	if !result.Infected {
+		scansTotal.WithLabelValues("clean").Inc()
+		return nil
+	}
+
+	scansTotal.WithLabelValues("infected").Inc()
+	slog.Warn("Malware detected in upload", "file", name, "signature", result.Signature)
+
+	if g.quarantine != nil {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			slog.Error("Failed to rewind infected file for quarantine", "file", name, "error", err)
+		} else if path, err := g.quarantine.Store(name, result.Signature, file); err != nil {
+			slog.Error("Failed to quarantine infected file", "file", name, "error", err)
+		} else {
+			quarantinedTotal.Inc()
+			slog.Info("Infected file quarantined", "file", name, "path", path)
+		}
+	}
+
+	return &InfectedError{Signature: result.Signature}
+}
+
+func (g *Guard) scan(ctx context.Context, file io.ReadSeeker) (Result, error) {
+	if g.scanner == nil {
+		return Result{}, errors.New("no scanner configured")
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return Result{}, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	return g.scanner.Scan(ctx, file)
+}
@@ -0,0 +1,185 @@
+package scanner_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/scanner"
+	"github.com/lexfrei/ne-stat-toboy/internal/scanner/clamdtest"
+)
+
+// startClamd starts a fake clamd and a client talking to it
+func startClamd(t *testing.T) (*clamdtest.Server, *scanner.Clamd) {
+	t.Helper()
+
+	server, err := clamdtest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start fake clamd: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	client, err := scanner.NewClamd(server.Address(), 5*time.Second)
+	if err != nil {
+		t.Fatalf("NewClamd: %v", err)
+	}
+	return server, client
+}
+
+// file returns content as a reader positioned at its end, as after saving
+// an upload, so that the guard has to rewind it
+func file(content string) io.ReadSeeker {
+	r := strings.NewReader(content)
+	_, _ = r.Seek(0, io.SeekEnd)
+	return r
+}
+
+func TestClamdPing(t *testing.T) {
+	_, client := startClamd(t)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
+
+func TestGuardAcceptsCleanFiles(t *testing.T) {
+	server, client := startClamd(t)
+	guard := scanner.NewGuard(client)
+
+	if err := guard.Check(context.Background(), "photo.jpg", file("just a photo")); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if server.Scans() != 1 {
+		t.Errorf("scans = %d, want 1", server.Scans())
+	}
+}
+
+func TestGuardRejectsInfectedFiles(t *testing.T) {
+	server, client := startClamd(t)
+	server.AddSignature("evil-macro", "Doc.Dropper.Agent")
+	guard := scanner.NewGuard(client, scanner.WithPolicy(scanner.FailOpen))
+
+	tests := []struct {
+		content   string
+		signature string
+	}{
+		{"prefix " + clamdtest.EICAR + " suffix", "Eicar-Test-Signature"},
+		{"harmless text with an evil-macro inside", "Doc.Dropper.Agent"},
+	}
+	for _, tt := range tests {
+		err := guard.Check(context.Background(), "upload.pdf", file(tt.content))
+
+		var infected *scanner.InfectedError
+		if !errors.As(err, &infected) {
+			t.Fatalf("Check = %v, want *InfectedError", err)
+		}
+		if infected.Signature != tt.signature {
+			t.Errorf("signature = %q, want %q", infected.Signature, tt.signature)
+		}
+	}
+}
+
+func TestGuardPolicyWhenScannerFails(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  scanner.Policy
+		failure func(*clamdtest.Server)
+		wantErr bool
+	}{
+		{"daemon down, fail-open", scanner.FailOpen, func(s *clamdtest.Server) { s.Close() }, false},
+		{"daemon down, fail-closed", scanner.FailClosed, func(s *clamdtest.Server) { s.Close() }, true},
+		{"scan error, fail-open", scanner.FailOpen, func(s *clamdtest.Server) { s.SetFailing(true) }, false},
+		{"scan error, fail-closed", scanner.FailClosed, func(s *clamdtest.Server) { s.SetFailing(true) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := startClamd(t)
+			tt.failure(server)
+			guard := scanner.NewGuard(client, scanner.WithPolicy(tt.policy))
+
+			err := guard.Check(context.Background(), "upload.pdf", file(clamdtest.EICAR))
+			if tt.wantErr && !errors.Is(err, scanner.ErrUnavailable) {
+				t.Errorf("Check = %v, want ErrUnavailable", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Check = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestGuardWithoutScannerFollowsPolicy(t *testing.T) {
+	if err := scanner.NewGuard(nil).Check(context.Background(), "a.txt", file("x")); !errors.Is(err, scanner.ErrUnavailable) {
+		t.Errorf("fail-closed Check = %v, want ErrUnavailable", err)
+	}
+	if err := scanner.NewGuard(nil, scanner.WithPolicy(scanner.FailOpen)).Check(context.Background(), "a.txt", file("x")); err != nil {
+		t.Errorf("fail-open Check = %v, want nil", err)
+	}
+}
+
+func TestGuardQuarantinesInfectedFiles(t *testing.T) {
+	_, client := startClamd(t)
+	dir := filepath.Join(t.TempDir(), "quarantine")
+	guard := scanner.NewGuard(client, scanner.WithQuarantine(scanner.NewQuarantine(dir)))
+
+	content := "payload " + clamdtest.EICAR
+	err := guard.Check(context.Background(), "../../invoice.pdf", file(content))
+	var infected *scanner.InfectedError
+	if !errors.As(err, &infected) {
+		t.Fatalf("Check = %v, want *InfectedError", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.quarantine"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("quarantined files = %v (%v), want one", files, err)
+	}
+
+	stored, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read quarantined file: %v", err)
+	}
+	if string(stored) != content {
+		t.Errorf("quarantined content = %q, want %q", stored, content)
+	}
+	info, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("quarantined file mode = %o, want 600", perm)
+	}
+
+	meta, err := os.ReadFile(strings.TrimSuffix(files[0], ".quarantine") + ".json")
+	if err != nil {
+		t.Fatalf("failed to read quarantine record: %v", err)
+	}
+	var record struct {
+		OriginalName string `json:"original_name"`
+		Signature    string `json:"signature"`
+		Size         int64  `json:"size"`
+	}
+	if err := json.Unmarshal(meta, &record); err != nil {
+		t.Fatalf("invalid quarantine record: %v", err)
+	}
+	if record.OriginalName != "invoice.pdf" || record.Signature != "Eicar-Test-Signature" || record.Size != int64(len(content)) {
+		t.Errorf("quarantine record = %+v", record)
+	}
+}
+
+func TestGuardDoesNotQuarantineCleanFiles(t *testing.T) {
+	_, client := startClamd(t)
+	dir := filepath.Join(t.TempDir(), "quarantine")
+	guard := scanner.NewGuard(client, scanner.WithQuarantine(scanner.NewQuarantine(dir)))
+
+	if err := guard.Check(context.Background(), "clean.txt", file("clean")); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("quarantine directory created for a clean file: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package signing_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/signing"
+)
+
+var now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestSignRoundTrip(t *testing.T) {
+	s := signing.New([]byte("secret"))
+
+	for _, value := range []string{"submission-1", "", "v1|analytics,video", "with|pipes|"} {
+		token := s.Sign(value, now.Add(time.Hour))
+		got, err := s.Verify(token, now)
+		if err != nil {
+			t.Errorf("Verify(Sign(%q)) = %v", value, err)
+			continue
+		}
+		if got != value {
+			t.Errorf("Verify(Sign(%q)) = %q", value, got)
+		}
+	}
+}
+
+func TestVerifyExpiry(t *testing.T) {
+	s := signing.New([]byte("secret"))
+	token := s.Sign("submission-1", now)
+
+	if _, err := s.Verify(token, now); err != nil {
+		t.Errorf("Verify at expiry = %v, want valid", err)
+	}
+	if _, err := s.Verify(token, now.Add(time.Second)); !errors.Is(err, signing.ErrExpired) {
+		t.Errorf("Verify after expiry = %v, want ErrExpired", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	s := signing.New([]byte("secret"))
+	token := s.Sign("submission-1", now.Add(time.Hour))
+	payload, sig, _ := strings.Cut(token, ".")
+	forged := signing.New([]byte("other")).Sign("submission-2", now.Add(time.Hour))
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := map[string]string{
+		"empty":             "",
+		"no signature":      payload,
+		"other key":         forged,
+		"swapped payload":   forgedPayload + "." + sig,
+		"truncated":         token[:len(token)-2],
+		"signature garbage": payload + ".!!!",
+	}
+	for name, token := range tests {
+		if _, err := s.Verify(token, now); !errors.Is(err, signing.ErrInvalid) {
+			t.Errorf("%s: Verify = %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestForSeparatesPurposes(t *testing.T) {
+	s := signing.New([]byte("secret"))
+	confirmation, consent := s.For("contact-confirmation"), s.For("consent")
+
+	token := consent.Sign("v1|analytics", now.Add(time.Hour))
+	if _, err := confirmation.Verify(token, now); !errors.Is(err, signing.ErrInvalid) {
+		t.Errorf("consent token accepted as a confirmation: %v", err)
+	}
+	if _, err := s.Verify(token, now); !errors.Is(err, signing.ErrInvalid) {
+		t.Errorf("derived token accepted by the parent key: %v", err)
+	}
+	if _, err := s.For("consent").Verify(token, now); err != nil {
+		t.Errorf("token rejected by the same purpose: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package storage
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile opens path without locking it: other processes are not excluded
+// on this platform
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	return f, nil
+}
+
+// unlockFile closes a file opened by lockFile
+func unlockFile(f *os.File) {
+	f.Close()
+}
@@ -0,0 +1,425 @@
+// Package storage provides persistence for contact form submissions.
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a submission does not exist
+var ErrNotFound = errors.New("submission not found")
+
+// Status is the moderation state of a submission
+type Status string
+
+// Submission statuses
+const (
+	StatusNew      Status = "new"
+	StatusSpam     Status = "spam"
+	StatusResolved Status = "resolved"
+)
+
+// Submission is a message sent through the contact form
+type Submission struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	Message     string     `json:"message"`
+	Locale      string     `json:"locale"`
+	Status      Status     `json:"status,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+
+	// Consent to personal data processing given with the submission
+	ConsentVersion string    `json:"consent_version"`
+	ConsentAt      time.Time `json:"consent_at"`
+
+	// TelegramMessageIDs are the chat messages that belong to this submission;
+	// a reply to any of them is routed back to the submitter.
+	TelegramMessageIDs []int64       `json:"telegram_message_ids,omitempty"`
+	Thread             []ThreadEntry `json:"thread,omitempty"`
+}
+
+// ThreadEntry is a reply sent to the submitter
+type ThreadEntry struct {
+	Author string    `json:"author"`
+	Text   string    `json:"text"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// Confirmed reports whether the submitter verified their email address
+func (s Submission) Confirmed() bool {
+	return s.ConfirmedAt != nil
+}
+
+// Store keeps submissions in memory and persists them to a JSON file.
+// Operations are serialized with other processes (such as the privacy CLI
+// commands) through a lock file, and their changes to the file are picked
+// up before every operation.
+type Store struct {
+	path string
+
+	mu          sync.Mutex
+	submissions map[string]*Submission
+	info        os.FileInfo
+}
+
+// Open loads the store from path, creating an empty one if the file does not exist
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:        path,
+		submissions: make(map[string]*Submission),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	unlock, err := s.lock()
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// lock acquires the store, also against other processes, and reloads the
+// file if it changed on disk. The returned function releases the store and
+// must be called even if reloading failed; writes must not go on then, as
+// they would overwrite changes that could not be read.
+func (s *Store) lock() (func(), error) {
+	s.mu.Lock()
+
+	file, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return s.mu.Unlock, err
+	}
+	unlock := func() {
+		unlockFile(file)
+		s.mu.Unlock()
+	}
+
+	return unlock, s.refresh()
+}
+
+// read acquires the store for reading; if the file cannot be reloaded, the
+// submissions already in memory are used
+func (s *Store) read() func() {
+	unlock, err := s.lock()
+	if err != nil {
+		slog.Error("Failed to reload submissions", "error", err)
+	}
+	return unlock
+}
+
+// refresh reloads the submissions if the file was replaced or modified. The
+// caller must hold the lock.
+func (s *Store) refresh() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.submissions = make(map[string]*Submission)
+		s.info = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat submissions: %w", err)
+	}
+	if s.info != nil && os.SameFile(info, s.info) &&
+		info.ModTime().Equal(s.info.ModTime()) && info.Size() == s.info.Size() {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read submissions: %w", err)
+	}
+
+	var list []*Submission
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to parse submissions %s: %w", s.path, err)
+	}
+
+	s.submissions = make(map[string]*Submission, len(list))
+	for _, sub := range list {
+		s.submissions[sub.ID] = sub
+	}
+	s.info = info
+
+	return nil
+}
+
+// Create stores a new submission, assigning its ID and creation time
+func (s *Store) Create(sub Submission) (Submission, error) {
+	id, err := newID()
+	if err != nil {
+		return Submission{}, err
+	}
+
+	sub.ID = id
+	if sub.Status == "" {
+		sub.Status = StatusNew
+	}
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now().UTC()
+	}
+
+	unlock, err := s.lock()
+	defer unlock()
+	if err != nil {
+		return Submission{}, err
+	}
+
+	s.submissions[sub.ID] = &sub
+	if err := s.save(); err != nil {
+		delete(s.submissions, sub.ID)
+		return Submission{}, err
+	}
+
+	return sub, nil
+}
+
+// Get returns the submission with the given ID
+func (s *Store) Get(id string) (Submission, error) {
+	defer s.read()()
+
+	sub, ok := s.submissions[id]
+	if !ok {
+		return Submission{}, ErrNotFound
+	}
+
+	return *sub, nil
+}
+
+// Update applies fn to the submission and persists the result
+func (s *Store) Update(id string, fn func(*Submission) error) (Submission, error) {
+	unlock, err := s.lock()
+	defer unlock()
+	if err != nil {
+		return Submission{}, err
+	}
+
+	current, ok := s.submissions[id]
+	if !ok {
+		return Submission{}, ErrNotFound
+	}
+
+	updated := *current
+	if err := fn(&updated); err != nil {
+		return Submission{}, err
+	}
+
+	s.submissions[id] = &updated
+	if err := s.save(); err != nil {
+		s.submissions[id] = current
+		return Submission{}, err
+	}
+
+	return updated, nil
+}
+
+// Confirm marks the submission's email address as verified
+func (s *Store) Confirm(id string, at time.Time) (Submission, error) {
+	return s.Update(id, func(sub *Submission) error {
+		if sub.ConfirmedAt == nil {
+			at = at.UTC()
+			sub.ConfirmedAt = &at
+		}
+		return nil
+	})
+}
+
+// SetStatus changes the moderation status of a submission
+func (s *Store) SetStatus(id string, status Status) (Submission, error) {
+	return s.Update(id, func(sub *Submission) error {
+		sub.Status = status
+		return nil
+	})
+}
+
+// FindByTelegramMessage returns the submission a Telegram chat message belongs to
+func (s *Store) FindByTelegramMessage(messageID int64) (Submission, error) {
+	defer s.read()()
+
+	for _, sub := range s.submissions {
+		for _, id := range sub.TelegramMessageIDs {
+			if id == messageID {
+				return *sub, nil
+			}
+		}
+	}
+
+	return Submission{}, ErrNotFound
+}
+
+// List returns all submissions, newest first
+func (s *Store) List() []Submission {
+	defer s.read()()
+
+	list := make([]Submission, 0, len(s.submissions))
+	for _, sub := range s.submissions {
+		list = append(list, *sub)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	return list
+}
+
+// FindByEmail returns all submissions sent from the address, oldest first
+func (s *Store) FindByEmail(email string) []Submission {
+	defer s.read()()
+
+	var found []Submission
+	for _, sub := range s.submissions {
+		if strings.EqualFold(sub.Email, email) {
+			found = append(found, *sub)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].CreatedAt.Before(found[j].CreatedAt)
+	})
+
+	return found
+}
+
+// DeleteByEmail removes all submissions sent from the address and returns how many were deleted
+func (s *Store) DeleteByEmail(email string) (int, error) {
+	return s.deleteWhere(func(sub *Submission) bool {
+		return strings.EqualFold(sub.Email, email)
+	})
+}
+
+// DeleteBefore removes all submissions created before t and returns how many were deleted
+func (s *Store) DeleteBefore(t time.Time) (int, error) {
+	return s.deleteWhere(func(sub *Submission) bool {
+		return sub.CreatedAt.Before(t)
+	})
+}
+
+func (s *Store) deleteWhere(match func(*Submission) bool) (int, error) {
+	unlock, err := s.lock()
+	defer unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := make(map[string]*Submission)
+	for id, sub := range s.submissions {
+		if match(sub) {
+			removed[id] = sub
+			delete(s.submissions, id)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	if err := s.save(); err != nil {
+		for id, sub := range removed {
+			s.submissions[id] = sub
+		}
+		return 0, err
+	}
+
+	return len(removed), nil
+}
+
+// Latest returns up to n newest submissions
+func (s *Store) Latest(n int) []Submission {
+	list := s.List()
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// CountByDay returns the number of submissions per day (UTC) for the last days, oldest first
+func (s *Store) CountByDay(days int, now time.Time) []DayCount {
+	counts := make(map[string]int)
+
+	unlock := s.read()
+	for _, sub := range s.submissions {
+		counts[sub.CreatedAt.UTC().Format(time.DateOnly)]++
+	}
+	unlock()
+
+	result := make([]DayCount, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		day := now.UTC().AddDate(0, 0, -i).Format(time.DateOnly)
+		result = append(result, DayCount{Date: day, Count: counts[day]})
+	}
+
+	return result
+}
+
+// DayCount is the number of submissions received on a date
+type DayCount struct {
+	Date  string
+	Count int
+}
+
+// save writes all submissions atomically. The caller must hold the write lock.
+func (s *Store) save() error {
+	list := make([]*Submission, 0, len(s.submissions))
+	for _, sub := range s.submissions {
+		list = append(list, sub)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal submissions: %w", err)
+	}
+
+	// A temporary file of our own, so that no other writer can clobber it
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create submissions file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write submissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write submissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write submissions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace submissions file: %w", err)
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat submissions: %w", err)
+	}
+	s.info = info
+
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate submission id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
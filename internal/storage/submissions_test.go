@@ -0,0 +1,174 @@
+package storage_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/storage"
+)
+
+func open(t *testing.T, path string) *storage.Store {
+	t.Helper()
+
+	store, err := storage.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return store
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "submissions.json")
+	store := open(t, path)
+
+	sub, err := store.Create(storage.Submission{Name: "Visitor", Email: "visitor@example.com", Message: "Hello"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if sub.ID == "" || sub.Status != storage.StatusNew || sub.CreatedAt.IsZero() {
+		t.Errorf("created submission = %+v", sub)
+	}
+
+	if _, err := store.Confirm(sub.ID, time.Now()); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if _, err := store.Get("missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Get of a missing ID = %v, want ErrNotFound", err)
+	}
+
+	reopened := open(t, path)
+	got, err := reopened.Get(sub.ID)
+	if err != nil {
+		t.Fatalf("Get after reopening: %v", err)
+	}
+	if !got.Confirmed() || got.Email != sub.Email {
+		t.Errorf("reopened submission = %+v", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("submissions file mode = %o, want 600", perm)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	for _, e := range entries {
+		if e.Name() != "submissions.json" && e.Name() != "submissions.json.lock" {
+			t.Errorf("temporary file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestStoresSharingAFileSeeEachOthersChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "submissions.json")
+	server, cli := open(t, path), open(t, path)
+
+	first, err := server.Create(storage.Submission{Email: "a@example.com"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := cli.Get(first.ID); err != nil {
+		t.Fatalf("submission created by the server not seen by the CLI: %v", err)
+	}
+
+	// Writes in quick succession, within the same modification time
+	second, err := cli.Create(storage.Submission{Email: "b@example.com"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	third, err := server.Create(storage.Submission{Email: "c@example.com"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for _, store := range []*storage.Store{server, cli} {
+		if n := len(store.List()); n != 3 {
+			t.Errorf("store lists %d submissions, want 3", n)
+		}
+	}
+
+	if deleted, err := cli.DeleteByEmail("B@example.com"); err != nil || deleted != 1 {
+		t.Fatalf("DeleteByEmail = %d, %v", deleted, err)
+	}
+	if _, err := server.Get(second.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("submission erased by the CLI still served: %v", err)
+	}
+	if _, err := server.Get(third.ID); err != nil {
+		t.Errorf("unrelated submission lost: %v", err)
+	}
+}
+
+func TestConcurrentWritersLoseNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "submissions.json")
+	stores := []*storage.Store{open(t, path), open(t, path), open(t, path)}
+
+	const perStore = 20
+	var wg sync.WaitGroup
+	for _, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perStore {
+				if _, err := store.Create(storage.Submission{Email: "visitor@example.com"}); err != nil {
+					t.Errorf("Create: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(open(t, path).List()); n != perStore*len(stores) {
+		t.Errorf("file holds %d submissions, want %d", n, perStore*len(stores))
+	}
+}
+
+func TestWritesAreRefusedWhenTheFileCannotBeRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "submissions.json")
+	store := open(t, path)
+	sub, err := store.Create(storage.Submission{Email: "visitor@example.com"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Another process leaves a file this one cannot parse
+	corrupt := []byte(`[{"id": "truncated`)
+	if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := store.Create(storage.Submission{Email: "other@example.com"}); err == nil {
+		t.Error("Create succeeded over an unreadable file")
+	}
+	if _, err := store.SetStatus(sub.ID, storage.StatusSpam); err == nil {
+		t.Error("SetStatus succeeded over an unreadable file")
+	}
+	if _, err := store.DeleteBefore(time.Now().Add(time.Hour)); err == nil {
+		t.Error("DeleteBefore succeeded over an unreadable file")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != string(corrupt) {
+		t.Errorf("unreadable file overwritten with %q", data)
+	}
+
+	// Reads keep serving what was loaded before
+	if _, err := store.Get(sub.ID); err != nil {
+		t.Errorf("Get = %v, want the submission in memory", err)
+	}
+
+	if _, err := storage.Open(path); err == nil || !strings.Contains(err.Error(), "parse") {
+		t.Errorf("Open of an unreadable file = %v, want a parse error", err)
+	}
+}
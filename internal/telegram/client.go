@@ -0,0 +1,172 @@
+// Package telegram is a small client for the Telegram Bot API.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the address of the public Bot API
+const DefaultBaseURL = "https://api.telegram.org"
+
+// allowedUpdates are the update types the bot subscribes to
+var allowedUpdates = []string{"message", "callback_query"}
+
+// Client calls Bot API methods for a single bot token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// ClientOption is a functional option for configuring the client
+type ClientOption func(*Client)
+
+// WithBaseURL points the client at another Bot API server, e.g. a local fake
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API calls
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient creates a new Bot API client
+func NewClient(token string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:    DefaultBaseURL,
+		token:      token,
+		httpClient: &http.Client{Timeout: 70 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// SendMessage sends a text message and returns the message as stored by Telegram
+func (c *Client) SendMessage(ctx context.Context, msg SendMessageRequest) (Message, error) {
+	var sent Message
+	err := c.call(ctx, "sendMessage", msg, &sent)
+	return sent, err
+}
+
+// AnswerCallbackQuery confirms a button press, optionally showing text to the user
+func (c *Client) AnswerCallbackQuery(ctx context.Context, callbackID, text string) error {
+	var ok bool
+	return c.call(ctx, "answerCallbackQuery", map[string]any{
+		"callback_query_id": callbackID,
+		"text":              text,
+	}, &ok)
+}
+
+// EditMessageReplyMarkup replaces the inline keyboard of a sent message
+func (c *Client) EditMessageReplyMarkup(ctx context.Context, chatID int64, messageID int64, markup *InlineKeyboardMarkup) error {
+	if markup == nil {
+		markup = &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+	}
+	return c.call(ctx, "editMessageReplyMarkup", map[string]any{
+		"chat_id":      chatID,
+		"message_id":   messageID,
+		"reply_markup": markup,
+	}, nil)
+}
+
+// GetUpdates long-polls for updates starting at offset
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": allowedUpdates,
+	}, &updates)
+	return updates, err
+}
+
+// SetWebhook registers url as the webhook; Telegram sends secret in X-Telegram-Bot-Api-Secret-Token
+func (c *Client) SetWebhook(ctx context.Context, url, secret string) error {
+	var ok bool
+	return c.call(ctx, "setWebhook", map[string]any{
+		"url":             url,
+		"secret_token":    secret,
+		"allowed_updates": allowedUpdates,
+	}, &ok)
+}
+
+// DeleteWebhook removes the webhook so that updates can be polled
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	var ok bool
+	return c.call(ctx, "deleteWebhook", map[string]any{}, &ok)
+}
+
+// GetMe returns the bot's own user, confirming the token and the API are reachable
+func (c *Client) GetMe(ctx context.Context) (User, error) {
+	var me User
+	err := c.call(ctx, "getMe", map[string]any{}, &me)
+	return me, err
+}
+
+// redact removes the bot token from the request URL carried by transport errors
+func (c *Client) redact(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	return &url.Error{
+		Op:  urlErr.Op,
+		URL: strings.ReplaceAll(urlErr.URL, c.token, "REDACTED"),
+		Err: urlErr.Err,
+	}
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, c.redact(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram %s request failed: %w", method, c.redact(err))
+	}
+	defer resp.Body.Close()
+
+	envelope := apiResponse[json.RawMessage]{}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("telegram API error: %s", resp.Status)
+	}
+	if !envelope.OK || resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram API error: %s: %s", resp.Status, envelope.Description)
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(envelope.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// UpdateHandler processes a single update
+type UpdateHandler func(ctx context.Context, update Update)
+
+// Poll receives updates with getUpdates until ctx is cancelled.
+// Any webhook is removed first because Telegram refuses to poll while one is set.
+func (c *Client) Poll(ctx context.Context, handle UpdateHandler) {
+	if err := c.DeleteWebhook(ctx); err != nil {
+		slog.Warn("Failed to delete Telegram webhook before polling", "error", err)
+	}
+
+	var offset int64
+	backoff := time.Second
+
+	for ctx.Err() == nil {
+		updates, err := c.GetUpdates(ctx, offset, 50*time.Second)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Failed to get Telegram updates", "error", err, "retry_in", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, time.Minute)
+			continue
+		}
+		backoff = time.Second
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			handle(ctx, update)
+		}
+	}
+}
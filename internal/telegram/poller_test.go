@@ -0,0 +1,97 @@
+package telegram_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram/telegramtest"
+)
+
+var member = telegram.User{ID: 7, FirstName: "Masha"}
+
+// expectUpdate waits for the next handled update and checks its ID
+func expectUpdate(t *testing.T, got <-chan telegram.Update, want telegram.Update) {
+	t.Helper()
+
+	select {
+	case u := <-got:
+		if u.UpdateID != want.UpdateID {
+			t.Fatalf("handled update %d, want %d", u.UpdateID, want.UpdateID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("update %d was not handled", want.UpdateID)
+	}
+}
+
+func TestPollHandlesEveryUpdateOnce(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+
+	client := telegram.NewClient("123:test-token", telegram.WithBaseURL(server.URL))
+	if err := client.SetWebhook(context.Background(), "https://example.com/telegram/webhook", "secret"); err != nil {
+		t.Fatalf("SetWebhook: %v", err)
+	}
+
+	first := server.Command(100, member, "/stats")
+	second := server.Command(100, member, "/latest")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan telegram.Update, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Poll(ctx, func(_ context.Context, u telegram.Update) { got <- u })
+	}()
+
+	expectUpdate(t, got, first)
+	expectUpdate(t, got, second)
+
+	// Updates arriving during a long poll are handled as well
+	third := server.Command(100, member, "/help")
+	expectUpdate(t, got, third)
+
+	// Confirmed updates must not be delivered again by the next poll
+	select {
+	case u := <-got:
+		t.Fatalf("update %d handled twice", u.UpdateID)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Poll did not return after cancellation")
+	}
+
+	if webhook := server.Webhook(); webhook != "" {
+		t.Errorf("webhook %q still set while polling", webhook)
+	}
+}
+
+func TestGetUpdatesConfirmsByOffset(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+
+	client := telegram.NewClient("123:test-token", telegram.WithBaseURL(server.URL))
+	first := server.Command(100, member, "/stats")
+	second := server.Command(100, member, "/latest")
+
+	updates, err := client.GetUpdates(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatalf("GetUpdates: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("got %d updates, want 2", len(updates))
+	}
+
+	updates, err = client.GetUpdates(context.Background(), first.UpdateID+1, 0)
+	if err != nil {
+		t.Fatalf("GetUpdates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].UpdateID != second.UpdateID {
+		t.Fatalf("got %+v after confirming the first update, want only %d", updates, second.UpdateID)
+	}
+}
@@ -0,0 +1,258 @@
+// Package telegramtest provides a fake Telegram Bot API server for tests.
+package telegramtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/telegram"
+)
+
+// Server is a fake Bot API. It records sent messages and serves queued updates to getUpdates.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	nextMessageID int64
+	nextUpdateID  int64
+	sent          []telegram.SendMessageRequest
+	answers       []string
+	markups       map[int64]*telegram.InlineKeyboardMarkup
+	updates       []telegram.Update
+	webhook       string
+}
+
+// NewServer starts a fake Bot API server
+func NewServer() *Server {
+	s := &Server{
+		nextMessageID: 1,
+		nextUpdateID:  1,
+		markups:       make(map[int64]*telegram.InlineKeyboardMarkup),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Sent returns the messages sent through sendMessage
+func (s *Server) Sent() []telegram.SendMessageRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]telegram.SendMessageRequest(nil), s.sent...)
+}
+
+// Answers returns the texts passed to answerCallbackQuery
+func (s *Server) Answers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.answers...)
+}
+
+// Markup returns the keyboard last set on the message with editMessageReplyMarkup
+func (s *Server) Markup(messageID int64) *telegram.InlineKeyboardMarkup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.markups[messageID]
+}
+
+// Webhook returns the currently registered webhook URL
+func (s *Server) Webhook() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.webhook
+}
+
+// PushUpdate queues an update for the next getUpdates call and returns it with its ID set
+func (s *Server) PushUpdate(update telegram.Update) telegram.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update.UpdateID = s.nextUpdateID
+	s.nextUpdateID++
+	s.updates = append(s.updates, update)
+	return update
+}
+
+// Reply queues a text message replying to the message with the given ID
+func (s *Server) Reply(chatID, replyTo int64, from telegram.User, text string) telegram.Update {
+	s.mu.Lock()
+	id := s.nextMessageID
+	s.nextMessageID++
+	s.mu.Unlock()
+
+	return s.PushUpdate(telegram.Update{
+		Message: &telegram.Message{
+			MessageID:      id,
+			From:           &from,
+			Chat:           telegram.Chat{ID: chatID, Type: "group"},
+			Date:           time.Now().Unix(),
+			Text:           text,
+			ReplyToMessage: &telegram.Message{MessageID: replyTo, Chat: telegram.Chat{ID: chatID, Type: "group"}},
+		},
+	})
+}
+
+// Command queues a text message such as "/stats" sent to the bot
+func (s *Server) Command(chatID int64, from telegram.User, text string) telegram.Update {
+	s.mu.Lock()
+	id := s.nextMessageID
+	s.nextMessageID++
+	s.mu.Unlock()
+
+	return s.PushUpdate(telegram.Update{
+		Message: &telegram.Message{
+			MessageID: id,
+			From:      &from,
+			Chat:      telegram.Chat{ID: chatID, Type: "group"},
+			Date:      time.Now().Unix(),
+			Text:      text,
+		},
+	})
+}
+
+// Press queues a callback query for an inline button on the given message
+func (s *Server) Press(chatID, messageID int64, from telegram.User, data string) telegram.Update {
+	s.mu.Lock()
+	id := strconv.FormatInt(s.nextUpdateID, 10)
+	s.mu.Unlock()
+
+	return s.PushUpdate(telegram.Update{
+		CallbackQuery: &telegram.CallbackQuery{
+			ID:   id,
+			From: from,
+			Message: &telegram.Message{
+				MessageID: messageID,
+				Chat:      telegram.Chat{ID: chatID, Type: "group"},
+			},
+			Data: data,
+		},
+	})
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// Paths look like /bot<token>/<method>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "bot") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var params map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: invalid JSON")
+		return
+	}
+
+	switch parts[1] {
+	case "getMe":
+		writeResult(w, telegram.User{ID: 1, IsBot: true, FirstName: "Test", Username: "test_bot"})
+	case "sendMessage":
+		var req telegram.SendMessageRequest
+		raw, _ := json.Marshal(params)
+		if err := json.Unmarshal(raw, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "Bad Request: invalid message")
+			return
+		}
+		writeResult(w, s.send(req))
+	case "getUpdates":
+		var offset, timeout int64
+		_ = json.Unmarshal(params["offset"], &offset)
+		_ = json.Unmarshal(params["timeout"], &timeout)
+		writeResult(w, s.waitPending(r, offset, time.Duration(timeout)*time.Second))
+	case "answerCallbackQuery":
+		var text string
+		_ = json.Unmarshal(params["text"], &text)
+		s.mu.Lock()
+		s.answers = append(s.answers, text)
+		s.mu.Unlock()
+		writeResult(w, true)
+	case "editMessageReplyMarkup":
+		var messageID int64
+		var markup telegram.InlineKeyboardMarkup
+		_ = json.Unmarshal(params["message_id"], &messageID)
+		_ = json.Unmarshal(params["reply_markup"], &markup)
+		s.mu.Lock()
+		s.markups[messageID] = &markup
+		s.mu.Unlock()
+		writeResult(w, true)
+	case "setWebhook":
+		var url string
+		_ = json.Unmarshal(params["url"], &url)
+		s.mu.Lock()
+		s.webhook = url
+		s.mu.Unlock()
+		writeResult(w, true)
+	case "deleteWebhook":
+		s.mu.Lock()
+		s.webhook = ""
+		s.mu.Unlock()
+		writeResult(w, true)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method not found")
+	}
+}
+
+func (s *Server) send(req telegram.SendMessageRequest) telegram.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, req)
+	if req.ReplyMarkup != nil {
+		s.markups[s.nextMessageID] = req.ReplyMarkup
+	}
+	msg := telegram.Message{
+		MessageID: s.nextMessageID,
+		From:      &telegram.User{ID: 1, IsBot: true, FirstName: "Fake Bot"},
+		Date:      time.Now().Unix(),
+		Text:      req.Text,
+	}
+	s.nextMessageID++
+	return msg
+}
+
+// waitPending emulates long polling: it returns as soon as updates are queued or timeout expires
+func (s *Server) waitPending(r *http.Request, offset int64, timeout time.Duration) []telegram.Update {
+	deadline := time.Now().Add(timeout)
+	for {
+		updates := s.pending(offset)
+		if len(updates) > 0 || time.Now().After(deadline) {
+			return updates
+		}
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// pending drops confirmed updates (below offset) and returns the rest
+func (s *Server) pending(offset int64) []telegram.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.updates[:0]
+	for _, u := range s.updates {
+		if u.UpdateID >= offset {
+			kept = append(kept, u)
+		}
+	}
+	s.updates = kept
+
+	return append([]telegram.Update{}, s.updates...)
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+func writeError(w http.ResponseWriter, status int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": status, "description": description})
+}
@@ -0,0 +1,157 @@
+// Package tracing sets up OpenTelemetry tracing and W3C trace context propagation.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the website in traces
+const ServiceName = "ne-stat-toboy"
+
+// Supported exporters
+const (
+	ExporterNone     = ""
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterStdout   = "stdout"
+)
+
+// Config selects where spans are sent
+type Config struct {
+	// Exporter is one of the Exporter constants; tracing is disabled if empty
+	Exporter string
+	// Endpoint is the collector address; the OTEL_EXPORTER_OTLP_* variables
+	// apply if empty
+	Endpoint string
+	// Insecure talks to the collector without TLS
+	Insecure bool
+	// SampleRatio is the fraction of new traces that are recorded
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and propagator. The returned
+// function flushes pending spans and must be called on shutdown. Context is
+// propagated even when tracing is disabled, so upstream traces stay connected.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterNone:
+		return nil, nil
+	case ExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP gRPC exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP HTTP exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
+
+// Tracer returns the tracer of the website's own spans
+func Tracer() trace.Tracer {
+	return otel.Tracer("github.com/lexfrei/ne-stat-toboy")
+}
+
+// Transport wraps base so that outgoing requests get client spans and carry
+// the trace context; http.DefaultTransport is used if base is nil. Secrets
+// such as API tokens in URL paths are masked in the recorded URL.
+func Transport(base http.RoundTripper, secrets ...string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if len(secrets) > 0 {
+		base = &redactingTransport{next: base, secrets: secrets}
+	}
+	return otelhttp.NewTransport(base)
+}
+
+// redactingTransport runs inside the otelhttp transport and overwrites the
+// URL it recorded on the client span
+type redactingTransport struct {
+	next    http.RoundTripper
+	secrets []string
+}
+
+func (t *redactingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	full := r.URL.Redacted()
+	for _, secret := range t.secrets {
+		if secret != "" {
+			full = strings.ReplaceAll(full, secret, "REDACTED")
+		}
+	}
+	trace.SpanFromContext(r.Context()).SetAttributes(semconv.URLFull(full))
+	return t.next.RoundTrip(r)
+}
@@ -0,0 +1,125 @@
+// Package upload stores user uploads after they pass the malware scanner.
+package upload
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lexfrei/ne-stat-toboy/internal/scanner"
+)
+
+var (
+	// ErrTooLarge is returned when an upload exceeds the size limit
+	ErrTooLarge = errors.New("upload too large")
+	// ErrUnknownCategory is returned for categories that do not accept uploads
+	ErrUnknownCategory = errors.New("unknown upload category")
+	// ErrFileType is returned when the file extension is not allowed for the category
+	ErrFileType = errors.New("file type not allowed")
+)
+
+// Categories lists the upload categories and the extensions each accepts
+var Categories = map[string][]string{
+	"headshot": {".jpg", ".jpeg", ".png", ".webp"},
+	"press":    {".pdf", ".doc", ".docx", ".jpg", ".jpeg", ".png", ".zip"},
+}
+
+// Store writes scanned uploads to disk. Every file goes through the scanner
+// guard before it is moved into the category directory.
+type Store struct {
+	dir     string
+	maxSize int64
+	guard   *scanner.Guard
+}
+
+// NewStore creates a new upload store
+func NewStore(dir string, maxSize int64, guard *scanner.Guard) *Store {
+	return &Store{
+		dir:     dir,
+		maxSize: maxSize,
+		guard:   guard,
+	}
+}
+
+// Save stores the upload and returns its generated file name.
+// Scanner errors (*scanner.InfectedError, scanner.ErrUnavailable) are passed through.
+func (s *Store) Save(ctx context.Context, category, filename string, r io.Reader) (string, error) {
+	ext, err := checkType(category, filename)
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir := filepath.Join(s.dir, ".tmp")
+	if err := os.MkdirAll(tmpDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(tmpDir, "upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	n, err := io.Copy(tmp, io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to receive upload: %w", err)
+	}
+	if n > s.maxSize {
+		return "", ErrTooLarge
+	}
+
+	if err := s.guard.Check(ctx, filename, tmp); err != nil {
+		return "", err
+	}
+
+	name, err := randomName(ext)
+	if err != nil {
+		return "", err
+	}
+
+	targetDir := filepath.Join(s.dir, category)
+	if err := os.MkdirAll(targetDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to finish upload: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(targetDir, name)); err != nil {
+		return "", fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	return name, nil
+}
+
+func checkType(category, filename string) (string, error) {
+	allowed, ok := Categories[category]
+	if !ok {
+		return "", ErrUnknownCategory
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, a := range allowed {
+		if ext == a {
+			return ext, nil
+		}
+	}
+
+	return "", ErrFileType
+}
+
+func randomName(ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate file name: %w", err)
+	}
+	return hex.EncodeToString(b) + ext, nil
+}
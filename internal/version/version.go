@@ -0,0 +1,69 @@
+// Package version describes the running build. Version, Commit and BuildTime
+// are set at build time:
+//
+//	go build -ldflags "-X github.com/lexfrei/ne-stat-toboy/internal/version.Version=1.2.3 ..."
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Set with -ldflags -X at build time
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information, falling back to the VCS details the Go
+// toolchain embeds when the ldflags were not set
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range build.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+
+	return info
+}
+
+// Collectors returns the build info gauge, always 1, labelled with the build details
+func Collectors() []prometheus.Collector {
+	info := Get()
+	buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "nestattoboy",
+		Name:      "build_info",
+		Help:      "Build information of the running binary; always 1.",
+		ConstLabels: prometheus.Labels{
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"build_time": info.BuildTime,
+			"go_version": info.GoVersion,
+		},
+	})
+	buildInfo.Set(1)
+	return []prometheus.Collector{buildInfo}
+}
@@ -0,0 +1,22 @@
+// Package web holds the front-end of the site: templ components and static assets.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// static contains the files served under /static; templates are compiled
+// into the binary by templ and need no embedding
+//
+//go:embed static
+var static embed.FS
+
+// StaticFS returns the embedded static files rooted at web/static
+func StaticFS() fs.FS {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,11 @@
+// First-party page view beacon: no cookies, no identifiers. Exported pages
+// name the API origin in data-api.
+(function () {
+    var endpoint = (document.currentScript.dataset.api || '') + '/api/view';
+    var data = JSON.stringify({ url: location.href, referrer: document.referrer });
+    if (navigator.sendBeacon) {
+        navigator.sendBeacon(endpoint, data);
+    } else {
+        fetch(endpoint, { method: 'POST', body: data, keepalive: true });
+    }
+})();
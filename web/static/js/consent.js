@@ -0,0 +1,35 @@
+// Consent banner of pages shared by every visitor: shown until a choice is
+// stored under the current policy version, which also ticks the categories
+// on the privacy page
+(function () {
+    var banner = document.querySelector('.consent-banner[data-consent-version]');
+    if (!banner) {
+        return;
+    }
+
+    // The cookie is a signed token: base64url("version|allowed|expires").signature
+    function storedChoice(version) {
+        var match = document.cookie.match(/(?:^|;\s*)consent=([A-Za-z0-9_-]+)\./);
+        if (!match) {
+            return null;
+        }
+        try {
+            var fields = atob(match[1].replace(/-/g, '+').replace(/_/g, '/')).split('|');
+            if (fields.length !== 3 || fields[0] !== version || Number(fields[2]) * 1000 < Date.now()) {
+                return null;
+            }
+            return fields[1] ? fields[1].split(',') : [];
+        } catch (e) {
+            return null;
+        }
+    }
+
+    var allowed = storedChoice(banner.dataset.consentVersion);
+    if (allowed === null) {
+        banner.hidden = false;
+        return;
+    }
+    document.querySelectorAll('.consent-settings input[name="category"]').forEach(function (input) {
+        input.checked = allowed.indexOf(input.value) !== -1;
+    });
+})();
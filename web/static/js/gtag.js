@@ -0,0 +1,8 @@
+// Google tag setup, loaded only with consent to analytics
+(function () {
+    var id = document.currentScript.dataset.tagId;
+    window.dataLayer = window.dataLayer || [];
+    function gtag(){dataLayer.push(arguments);}
+    gtag('js', new Date());
+    gtag('config', id);
+})();
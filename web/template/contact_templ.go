@@ -1,6 +1,6 @@
 // Code generated by templ - DO NOT EDIT.
 
-// templ: version: v0.3.857
+// templ: version: v0.3.865
 package template
 
 //lint:file-ignore SA4006 This context is only used if a nested component is present.
@@ -14,6 +14,8 @@ import "github.com/lexfrei/ne-stat-toboy/internal/model"
 type ContactData struct {
 	Film      model.FilmInfo
 	CSRFToken string
+	// APIURL is prepended to form actions when the page is served from another origin
+	APIURL string
 }
 
 func Contact(film model.FilmInfo) templ.Component {
@@ -56,7 +58,7 @@ func Contact(film model.FilmInfo) templ.Component {
 			var templ_7745c5c3_Var3 string
 			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(film.Director)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 20, Col: 42}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 22, Col: 42}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 			if templ_7745c5c3_Err != nil {
@@ -91,7 +93,7 @@ func Contact(film model.FilmInfo) templ.Component {
 			var templ_7745c5c3_Var4 string
 			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(film.Producer)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 33, Col: 42}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 35, Col: 42}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
@@ -119,7 +121,20 @@ func Contact(film model.FilmInfo) templ.Component {
 					}
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</div></div><div class=\"contact-form\"><h2>Связаться с нами</h2><form hx-post=\"/api/contact\" hx-swap=\"outerHTML\" hx-indicator=\"#form-indicator\" hx-on::after-request=\"showFormErrors(event)\"><div id=\"form-indicator\" class=\"htmx-indicator\">Отправка...</div><input type=\"hidden\" name=\"_csrf\" id=\"csrf-token\"><div class=\"form-group\"><label for=\"name\">Имя</label> <input type=\"text\" id=\"name\" name=\"name\" required><div class=\"error-message\" id=\"name-error\"></div></div><div class=\"form-group\"><label for=\"email\">Email</label> <input type=\"email\" id=\"email\" name=\"email\" required><div class=\"error-message\" id=\"email-error\"></div></div><div class=\"form-group\"><label for=\"message\">Сообщение</label> <textarea id=\"message\" name=\"message\" rows=\"5\" required></textarea><div class=\"error-message\" id=\"message-error\"></div></div><button type=\"submit\" class=\"btn\">Отправить</button></form></div></div></section><script>\n            // Set CSRF token from cookie\n            function getCookie(name) {\n                const value = `; ${document.cookie}`;\n                const parts = value.split(`; ${name}=`);\n                if (parts.length === 2) return parts.pop().split(';').shift();\n            }\n            \n            document.addEventListener('DOMContentLoaded', () => {\n                const csrfToken = getCookie('csrf');\n                if (csrfToken) {\n                    document.getElementById('csrf-token').value = csrfToken;\n                }\n            });\n            \n            function showFormErrors(event) {\n                // Reset any existing errors\n                document.querySelectorAll('.error-message').forEach(el => {\n                    el.textContent = '';\n                    el.style.display = 'none';\n                });\n                \n                // Check if there are errors to show\n                if (event.detail.xhr.status === 400) {\n                    try {\n                        const response = JSON.parse(event.detail.xhr.responseText);\n                        if (response.errors) {\n                            // Show each error\n                            Object.keys(response.errors).forEach(field => {\n                                const errorElement = document.getElementById(`${field}-error`);\n                                if (errorElement) {\n                                    errorElement.textContent = response.errors[field];\n                                    errorElement.style.display = 'block';\n                                }\n                            });\n                        }\n                    } catch (e) {\n                        console.error('Error parsing response:', e);\n                    }\n                    \n                    // Prevent the default swap behavior\n                    event.detail.shouldSwap = false;\n                }\n            }\n        </script>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</div></div><div class=\"contact-form\"><h2>Связаться с нами</h2><form id=\"contact-form\" hx-post=\"/api/contact\" hx-swap=\"outerHTML\" hx-indicator=\"#form-indicator\"><div id=\"form-indicator\" class=\"htmx-indicator\">Отправка...</div><input type=\"hidden\" name=\"_csrf\" id=\"csrf-token\"><div class=\"form-group\"><label for=\"name\">Имя</label> <input type=\"text\" id=\"name\" name=\"name\" required><div class=\"error-message\" id=\"name-error\"></div></div><div class=\"form-group\"><label for=\"email\">Email</label> <input type=\"email\" id=\"email\" name=\"email\" required><div class=\"error-message\" id=\"email-error\"></div></div><div class=\"form-group\"><label for=\"message\">Сообщение</label> <textarea id=\"message\" name=\"message\" rows=\"5\" required></textarea><div class=\"error-message\" id=\"message-error\"></div></div><div class=\"form-group consent\"><label for=\"consent\"><input type=\"checkbox\" id=\"consent\" name=\"consent\" value=\"1\" required> <span>Я даю согласие на обработку моих персональных данных в соответствии с <a href=\"/privacy\" target=\"_blank\">политикой конфиденциальности</a></span></label><div class=\"error-message\" id=\"consent-error\"></div></div><button type=\"submit\" class=\"btn\">Отправить</button></form></div></div></section><script nonce=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var5 string
+			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(templ.GetNonce(ctx))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 86, Col: 43}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "\">\n            // Set CSRF token from cookie\n            function getCookie(name) {\n                const value = `; ${document.cookie}`;\n                const parts = value.split(`; ${name}=`);\n                if (parts.length === 2) return parts.pop().split(';').shift();\n            }\n            \n            document.addEventListener('DOMContentLoaded', () => {\n                const csrfToken = getCookie('csrf');\n                if (csrfToken) {\n                    document.getElementById('csrf-token').value = csrfToken;\n                }\n            });\n            \n            function showFormErrors(event) {\n                // Reset any existing errors\n                document.querySelectorAll('.error-message').forEach(el => {\n                    el.textContent = '';\n                    el.style.display = 'none';\n                });\n                \n                // Check if there are errors to show\n                if (event.detail.xhr.status === 400) {\n                    try {\n                        const response = JSON.parse(event.detail.xhr.responseText);\n                        if (response.errors) {\n                            // Show each error\n                            Object.keys(response.errors).forEach(field => {\n                                const errorElement = document.getElementById(`${field}-error`);\n                                if (errorElement) {\n                                    errorElement.textContent = response.errors[field];\n                                    errorElement.style.display = 'block';\n                                }\n                            });\n                        }\n                    } catch (e) {\n                        console.error('Error parsing response:', e);\n                    }\n                    \n                    // Prevent the default swap behavior\n                    event.detail.shouldSwap = false;\n                }\n            }\n\n            document.body.addEventListener('htmx:afterRequest', event => {\n                if (event.detail.elt.id === 'contact-form') {\n                    showFormErrors(event);\n                }\n            });\n        </script>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -149,12 +164,12 @@ func ContactWithCSRF(data ContactData) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var5 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var5 == nil {
-			templ_7745c5c3_Var5 = templ.NopComponent
+		templ_7745c5c3_Var6 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var6 == nil {
+			templ_7745c5c3_Var6 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Var6 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_Var7 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
 			if !templ_7745c5c3_IsBuffer {
@@ -166,96 +181,132 @@ func ContactWithCSRF(data ContactData) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<section class=\"contact\"><div class=\"container\"><h1>Контакты</h1><div class=\"contact-info\"><div class=\"contact-item\"><h3>Режиссер</h3><p>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<section class=\"contact\"><div class=\"container\"><h1>Контакты</h1><div class=\"contact-info\"><div class=\"contact-item\"><h3>Режиссер</h3><p>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var7 string
-			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(data.Film.Director)
+			var templ_7745c5c3_Var8 string
+			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(data.Film.Director)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 133, Col: 47}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 149, Col: 47}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</p>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "</p>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			for _, member := range data.Film.TeamMembers {
 				if member.Role == "Режиссер" && member.Email != "" {
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<p>Email: <a href=\"mailto:me@masha.film\">me@masha.film</a></p>")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "<p>Email: <a href=\"mailto:me@masha.film\">me@masha.film</a></p>")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, " ")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, " ")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 				if member.Role == "Режиссер" && member.Phone != "" {
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "<p>Телефон: <a href=\"tel:+79164671300\">+7 916 467 13 00</a></p>")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "<p>Телефон: <a href=\"tel:+79164671300\">+7 916 467 13 00</a></p>")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</div><div class=\"contact-item\"><h3>Продюсер</h3><p>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "</div><div class=\"contact-item\"><h3>Продюсер</h3><p>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var8 string
-			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(data.Film.Producer)
+			var templ_7745c5c3_Var9 string
+			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(data.Film.Producer)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 146, Col: 47}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 162, Col: 47}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "</p>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</p>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			for _, member := range data.Film.TeamMembers {
 				if member.Role == "Продюсер" && member.Email != "" {
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "<p>Email: <a href=\"mailto:minin-ilya@yandex.ru\">minin-ilya@yandex.ru</a></p>")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "<p>Email: <a href=\"mailto:minin-ilya@yandex.ru\">minin-ilya@yandex.ru</a></p>")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, " ")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, " ")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 				if member.Role == "Продюсер" && member.Phone != "" {
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<p>Телефон: <a href=\"tel:+79110904359\">+7 911 090 4359</a></p>")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "<p>Телефон: <a href=\"tel:+79110904359\">+7 911 090 4359</a></p>")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "</div></div><div class=\"contact-form\"><h2>Связаться с нами</h2><form hx-post=\"/api/contact\" hx-swap=\"outerHTML\" hx-indicator=\"#form-indicator\" hx-on::after-request=\"showFormErrors(event)\"><div id=\"form-indicator\" class=\"htmx-indicator\">Отправка...</div><input type=\"hidden\" name=\"_csrf\" value=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "</div></div><div class=\"contact-form\"><h2>Связаться с нами</h2><form id=\"contact-form\" hx-post=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var9 string
-			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(data.CSRFToken)
+			var templ_7745c5c3_Var10 string
+			templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(data.APIURL + "/api/contact")
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 163, Col: 80}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 176, Col: 82}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "\" hx-swap=\"outerHTML\" hx-indicator=\"#form-indicator\"><div id=\"form-indicator\" class=\"htmx-indicator\">Отправка...</div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if data.CSRFToken != "" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "<input type=\"hidden\" name=\"_csrf\" value=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var11 string
+				templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(data.CSRFToken)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 180, Col: 84}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "<div class=\"form-group\"><label for=\"name\">Имя</label> <input type=\"text\" id=\"name\" name=\"name\" required><div class=\"error-message\" id=\"name-error\"></div></div><div class=\"form-group\"><label for=\"email\">Email</label> <input type=\"email\" id=\"email\" name=\"email\" required><div class=\"error-message\" id=\"email-error\"></div></div><div class=\"form-group\"><label for=\"message\">Сообщение</label> <textarea id=\"message\" name=\"message\" rows=\"5\" required></textarea><div class=\"error-message\" id=\"message-error\"></div></div><div class=\"form-group consent\"><label for=\"consent\"><input type=\"checkbox\" id=\"consent\" name=\"consent\" value=\"1\" required> <span>Я даю согласие на обработку моих персональных данных в соответствии с <a href=\"/privacy\" target=\"_blank\">политикой конфиденциальности</a></span></label><div class=\"error-message\" id=\"consent-error\"></div></div><button type=\"submit\" class=\"btn\">Отправить</button></form></div></div></section><script nonce=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var12 string
+			templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(templ.GetNonce(ctx))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 215, Col: 43}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "\"><div class=\"form-group\"><label for=\"name\">Имя</label> <input type=\"text\" id=\"name\" name=\"name\" required><div class=\"error-message\" id=\"name-error\"></div></div><div class=\"form-group\"><label for=\"email\">Email</label> <input type=\"email\" id=\"email\" name=\"email\" required><div class=\"error-message\" id=\"email-error\"></div></div><div class=\"form-group\"><label for=\"message\">Сообщение</label> <textarea id=\"message\" name=\"message\" rows=\"5\" required></textarea><div class=\"error-message\" id=\"message-error\"></div></div><button type=\"submit\" class=\"btn\">Отправить</button></form></div></div></section><script>\n            function showFormErrors(event) {\n                // Reset any existing errors\n                document.querySelectorAll('.error-message').forEach(el => {\n                    el.textContent = '';\n                    el.style.display = 'none';\n                });\n                \n                // Check if there are errors to show\n                if (event.detail.xhr.status === 400) {\n                    try {\n                        const response = JSON.parse(event.detail.xhr.responseText);\n                        if (response.errors) {\n                            // Show each error\n                            Object.keys(response.errors).forEach(field => {\n                                const errorElement = document.getElementById(`${field}-error`);\n                                if (errorElement) {\n                                    errorElement.textContent = response.errors[field];\n                                    errorElement.style.display = 'block';\n                                }\n                            });\n                        }\n                    } catch (e) {\n                        console.error('Error parsing response:', e);\n                    }\n                    \n                    // Prevent the default swap behavior\n                    event.detail.shouldSwap = false;\n                }\n            }\n        </script>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "\">\n            function showFormErrors(event) {\n                // Reset any existing errors\n                document.querySelectorAll('.error-message').forEach(el => {\n                    el.textContent = '';\n                    el.style.display = 'none';\n                });\n                \n                // Check if there are errors to show\n                if (event.detail.xhr.status === 400) {\n                    try {\n                        const response = JSON.parse(event.detail.xhr.responseText);\n                        if (response.errors) {\n                            // Show each error\n                            Object.keys(response.errors).forEach(field => {\n                                const errorElement = document.getElementById(`${field}-error`);\n                                if (errorElement) {\n                                    errorElement.textContent = response.errors[field];\n                                    errorElement.style.display = 'block';\n                                }\n                            });\n                        }\n                    } catch (e) {\n                        console.error('Error parsing response:', e);\n                    }\n                    \n                    // Prevent the default swap behavior\n                    event.detail.shouldSwap = false;\n                }\n            }\n\n            document.body.addEventListener('htmx:afterRequest', event => {\n                if (event.detail.elt.id === 'contact-form') {\n                    showFormErrors(event);\n                }\n            });\n        </script>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			return nil
 		})
-		templ_7745c5c3_Err = Layout("Контакты").Render(templ.WithChildren(ctx, templ_7745c5c3_Var6), templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = Layout("Контакты").Render(templ.WithChildren(ctx, templ_7745c5c3_Var7), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -279,12 +330,159 @@ func ContactSuccess() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var10 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var10 == nil {
-			templ_7745c5c3_Var10 = templ.NopComponent
+		templ_7745c5c3_Var13 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var13 == nil {
+			templ_7745c5c3_Var13 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, "<div class=\"success-message\"><h3>Сообщение отправлено!</h3><p>Спасибо за ваше сообщение. Мы свяжемся с вами в ближайшее время.</p></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// ContactConfirm asks the submitter to confirm their address. Mail scanners
+// follow links in emails, so only the form submission confirms.
+func ContactConfirm(token, csrfToken string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var14 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var14 == nil {
+			templ_7745c5c3_Var14 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Var15 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "<section class=\"contact\"><div class=\"container\"><div class=\"success-message\"><h3>Подтвердите адрес</h3><p>Нажмите кнопку, чтобы подтвердить ваше сообщение, и мы ответим на указанный email.</p><form method=\"post\" action=\"/contact/confirm\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if csrfToken != "" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "<input type=\"hidden\" name=\"_csrf\" value=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var16 string
+				templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(csrfToken)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 273, Col: 79}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, "\"> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "<input type=\"hidden\" name=\"token\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var17 string
+			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(token)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/template/contact.templ`, Line: 275, Col: 71}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "\"> <button type=\"submit\" class=\"btn\">Подтвердить</button></form></div></div></section>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = Layout("Подтверждение").Render(templ.WithChildren(ctx, templ_7745c5c3_Var15), templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+func ContactConfirmed(confirmed bool) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var18 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var18 == nil {
+			templ_7745c5c3_Var18 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "<div class=\"success-message\"><h3>Сообщение отправлено!</h3><p>Спасибо за ваше сообщение. Мы свяжемся с вами в ближайшее время.</p></div>")
+		templ_7745c5c3_Var19 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "<section class=\"contact\"><div class=\"container\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if confirmed {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "<div class=\"success-message\"><h3>Адрес подтвержден!</h3><p>Спасибо! Ваше сообщение подтверждено, мы ответим на указанный email.</p></div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "<div class=\"success-message\"><h3>Ссылка недействительна</h3><p>Ссылка для подтверждения устарела или повреждена. Если вы недавно писали нам, отправьте сообщение еще раз.</p></div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "</div></section>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = Layout("Подтверждение").Render(templ.WithChildren(ctx, templ_7745c5c3_Var19), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}